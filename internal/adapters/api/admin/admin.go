@@ -0,0 +1,118 @@
+// Package admin serves operational endpoints on a separate listener that is meant to stay private:
+// Prometheus metrics, the full readiness report, runtime log level, pprof, goroutine dumps and build info.
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gophermart/internal/adapters/health"
+	"gophermart/internal/buildinfo"
+	"gophermart/internal/config"
+	"gophermart/internal/logger"
+	"gophermart/internal/metrics"
+	"net/http"
+	"net/http/pprof"
+	rpprof "runtime/pprof"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+)
+
+const goroutineDumpDebug = 2
+
+type API struct {
+	srv             *http.Server
+	shutdownTimeout time.Duration
+}
+
+func NewAPI(cfg *config.Config, probe *health.Health) *API {
+	r := chi.NewRouter()
+	r.Handle("/metrics", metrics.Handler())
+	r.Get("/debug/ready", Readiness(probe))
+	r.Get("/debug/buildinfo", BuildInfo)
+	r.Get("/debug/goroutines", Goroutines)
+	r.Method(http.MethodGet, "/debug/loglevel", logger.Level)
+	r.Put("/debug/loglevel", SetLogLevel)
+	r.HandleFunc("/debug/pprof/", pprof.Index)
+	r.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	r.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	r.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	r.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	r.HandleFunc("/debug/pprof/{profile}", pprof.Index)
+	return &API{
+		srv: &http.Server{
+			Addr:    cfg.AdminAddress,
+			Handler: r,
+		},
+		shutdownTimeout: time.Duration(cfg.ShutdownTimeout) * time.Second,
+	}
+}
+
+// Run serves until ctx is done, then waits for in-flight requests to finish within the shutdown timeout.
+func (a *API) Run(ctx context.Context) error {
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-ctx.Done()
+		// ctx is already done here, in-flight requests get a fresh deadline to finish.
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), a.shutdownTimeout)
+		defer cancel()
+		if err := a.srv.Shutdown(shutdownCtx); err != nil {
+			logger.Log.Info("admin api shutdown: ", zap.Error(err))
+		}
+	}()
+	logger.Log.Info("admin api listening", zap.String("address", a.srv.Addr))
+	if err := a.srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Log.Error("error occurred during running admin api: ", zap.Error(err))
+		return fmt.Errorf("failed run admin api: %w", err)
+	}
+	<-shutdownDone
+	return nil
+}
+
+func BuildInfo(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(buildinfo.Read()); err != nil {
+		logger.Log.Error("error encoding build info", zap.Error(err))
+	}
+}
+
+// Readiness serves the full readiness report, including the details and errors of every check.
+func Readiness(probe *health.Health) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		report := probe.Ready(req.Context())
+		w.Header().Set("Content-Type", "application/json")
+		if report.Status != health.StatusUp {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			logger.Log.Error("error encoding readiness report", zap.Error(err))
+		}
+	}
+}
+
+// Goroutines dumps the stacks of all goroutines in the same format as an unrecovered panic.
+func Goroutines(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if err := rpprof.Lookup("goroutine").WriteTo(w, goroutineDumpDebug); err != nil {
+		logger.Log.Error("error writing goroutine dump", zap.Error(err))
+	}
+}
+
+// SetLogLevel accepts {"level":"debug"} like zap's own handler and logs who changed the level to what.
+func SetLogLevel(w http.ResponseWriter, req *http.Request) {
+	previous := logger.Level.Level()
+	ww := middleware.NewWrapResponseWriter(w, req.ProtoMajor)
+	logger.Level.ServeHTTP(ww, req)
+	if ww.Status() == http.StatusOK && logger.Level.Level() != previous {
+		logger.Log.Warn("log level changed",
+			zap.Stringer("from", previous),
+			zap.Stringer("to", logger.Level.Level()),
+			zap.String("remote_addr", req.RemoteAddr),
+		)
+	}
+}
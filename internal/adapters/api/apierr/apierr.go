@@ -0,0 +1,55 @@
+// Package apierr maps domain errors onto transport-level status codes shared by the REST and gRPC APIs.
+package apierr
+
+import (
+	"errors"
+	"gophermart/internal/errs"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+)
+
+const CodeInternalError = "internal_error"
+
+type Mapping struct {
+	Err    error
+	Status int
+	Code   string
+	GRPC   codes.Code
+}
+
+// mappings is checked in order, so more specific sentinels go first.
+var mappings = []Mapping{
+	{errs.ErrValidationError, http.StatusBadRequest, "validation_error", codes.InvalidArgument},
+	{errs.ErrPayloadTooLarge, http.StatusRequestEntityTooLarge, "payload_too_large", codes.ResourceExhausted},
+	{errs.ErrMalformedBody, http.StatusBadRequest, "malformed_body", codes.InvalidArgument},
+	{errs.ErrUnsupportedMediaType, http.StatusUnsupportedMediaType, "unsupported_media_type", codes.InvalidArgument},
+	{errs.ErrUnauthorized, http.StatusUnauthorized, "unauthorized", codes.Unauthenticated},
+	{errs.ErrForbidden, http.StatusForbidden, "forbidden", codes.PermissionDenied},
+	{errs.ErrTooManyRequests, http.StatusTooManyRequests, "rate_limited", codes.ResourceExhausted},
+	{errs.ErrNotFound, http.StatusNotFound, "not_found", codes.NotFound},
+	{errs.ErrLoginAlreadyExist, http.StatusConflict, "login_already_exists", codes.AlreadyExists},
+	{errs.ErrInvalidLoginOrPassword, http.StatusUnauthorized, "invalid_credentials", codes.Unauthenticated},
+	{errs.ErrInvalidResetToken, http.StatusBadRequest, "invalid_reset_token", codes.InvalidArgument},
+	{errs.ErrInvalidOrderNumber, http.StatusUnprocessableEntity, "invalid_order_number", codes.InvalidArgument},
+	{errs.ErrUnreachableOrder, http.StatusConflict, "order_owned_by_another_user", codes.AlreadyExists},
+	{errs.ErrWithdrawAlreadyExist, http.StatusConflict, "withdrawal_already_exists", codes.AlreadyExists},
+	{errs.ErrNotEnoughFunds, http.StatusPaymentRequired, "not_enough_funds", codes.FailedPrecondition},
+	{errs.ErrSecondFactorRequired, http.StatusForbidden, "second_factor_required", codes.PermissionDenied},
+	{errs.ErrInvalidSecondFactor, http.StatusUnauthorized, "invalid_second_factor", codes.Unauthenticated},
+	{errs.ErrTwoFactorAlreadyEnabled, http.StatusConflict, "two_factor_already_enabled", codes.FailedPrecondition},
+	{errs.ErrTwoFactorNotEnabled, http.StatusConflict, "two_factor_not_enabled", codes.FailedPrecondition},
+	{errs.ErrIdempotencyKeyInUse, http.StatusConflict, "idempotency_key_in_use", codes.Aborted},
+	{errs.ErrIdempotencyKeyReused, http.StatusUnprocessableEntity, "idempotency_key_reused", codes.InvalidArgument},
+}
+
+// Lookup returns the mapping of the first sentinel err wraps.
+// Unknown errors map to an internal error.
+func Lookup(err error) (Mapping, bool) {
+	for _, m := range mappings {
+		if errors.Is(err, m.Err) {
+			return m, true
+		}
+	}
+	return Mapping{Status: http.StatusInternalServerError, Code: CodeInternalError, GRPC: codes.Internal}, false
+}
@@ -0,0 +1,83 @@
+// Package grpcapi exposes the user-facing operations over gRPC next to the REST API.
+package grpcapi
+
+//go:generate protoc --go_out=pb --go_opt=paths=source_relative --go-grpc_out=pb --go-grpc_opt=paths=source_relative -I proto gophermart.proto
+
+import (
+	"context"
+	"fmt"
+	"gophermart/internal/adapters/api/grpcapi/pb"
+	"gophermart/internal/adapters/ratelimit"
+	"gophermart/internal/adapters/tlsconfig"
+	"gophermart/internal/config"
+	"gophermart/internal/core/domain"
+	"gophermart/internal/logger"
+	"net"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+type Service interface {
+	CreateUser(ctx context.Context, user *domain.UserIn) error
+	CreateToken(ctx context.Context, user *domain.UserIn) (*domain.Token, error)
+	CompleteMFALogin(ctx context.Context, mfaIn *domain.MFALoginIn) (*domain.Token, error)
+	ParseToken(accessToken string) (*domain.Principal, error)
+	ParseAPIKey(ctx context.Context, rawKey string) (*domain.Principal, error)
+	CreateOrder(ctx context.Context, userID int, order *domain.OrderIn) error
+	GetAllOrders(ctx context.Context, query *domain.OrderQuery) (*domain.OrderPage, error)
+	GetBalance(ctx context.Context, userID int) (*domain.BalanceOut, error)
+	WithdrawBonuses(ctx context.Context, userID int, withdraw *domain.WithdrawalIn) error
+	GetAllWithdrawals(ctx context.Context, query *domain.WithdrawalQuery) (*domain.WithdrawalPage, error)
+}
+
+type Handler struct {
+	pb.UnimplementedGophermartServer
+	service Service
+	config  *config.Config
+	limiter ratelimit.Store
+	certs   *tlsconfig.Reloader
+}
+
+type API struct {
+	srv     *grpc.Server
+	address string
+}
+
+// NewAPI serves over TLS with the REST API's certificates when certs is set.
+func NewAPI(cfg *config.Config, srv Service, limiter ratelimit.Store, certs *tlsconfig.Reloader) *API {
+	h := &Handler{service: srv, config: cfg, limiter: limiter, certs: certs}
+	opts := []grpc.ServerOption{grpc.ChainUnaryInterceptor(
+		loggingInterceptor,
+		h.rateLimitInterceptor,
+		h.authInterceptor,
+	)}
+	if certs != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(certs.TLSConfig())))
+	}
+	server := grpc.NewServer(opts...)
+	pb.RegisterGophermartServer(server, h)
+	return &API{
+		srv:     server,
+		address: cfg.GRPCAddress,
+	}
+}
+
+func (a *API) Run(ctx context.Context) error {
+	var lc net.ListenConfig
+	listener, err := lc.Listen(ctx, "tcp", a.address)
+	if err != nil {
+		return fmt.Errorf("failed to listen grpc address: %w", err)
+	}
+	go func() {
+		<-ctx.Done()
+		a.srv.GracefulStop()
+	}()
+	logger.Log.Info("grpc api listening", zap.String("address", a.address))
+	if err = a.srv.Serve(listener); err != nil {
+		logger.Log.Error("error occurred during running grpc api: ", zap.Error(err))
+		return fmt.Errorf("failed run grpc api: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"gophermart/internal/adapters/api/grpcapi/pb"
+	"gophermart/internal/adapters/api/validation"
+	"gophermart/internal/core/domain"
+	"gophermart/internal/errs"
+	"gophermart/internal/logger"
+
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (h *Handler) Register(ctx context.Context, req *pb.Credentials) (*pb.TokenResponse, error) {
+	user := domain.UserIn{Login: req.GetLogin(), Password: req.GetPassword()}
+	if err := validation.ValidateUserIn(&user); err != nil {
+		return nil, toStatus(err)
+	}
+	if err := h.service.CreateUser(ctx, &user); err != nil {
+		return nil, toStatus(err)
+	}
+	return h.createToken(ctx, &user)
+}
+
+func (h *Handler) Login(ctx context.Context, req *pb.Credentials) (*pb.TokenResponse, error) {
+	user := domain.UserIn{Login: req.GetLogin(), Password: req.GetPassword()}
+	if err := validation.ValidateUserIn(&user); err != nil {
+		return nil, toStatus(err)
+	}
+	return h.createToken(ctx, &user)
+}
+
+// LoginMFA reports every failure other than a wrong code as invalid credentials, like the REST sign-in.
+func (h *Handler) LoginMFA(ctx context.Context, req *pb.LoginMFARequest) (*pb.TokenResponse, error) {
+	mfaIn := domain.MFALoginIn{MFAToken: req.GetMfaToken(), Code: req.GetCode()}
+	if err := validation.ValidateMFALoginIn(&mfaIn); err != nil {
+		return nil, toStatus(err)
+	}
+	token, err := h.service.CompleteMFALogin(ctx, &mfaIn)
+	if err != nil {
+		if !errors.Is(err, errs.ErrInvalidSecondFactor) {
+			logger.FromContext(ctx).Info("mfa login failed", zap.Error(err))
+			err = errs.ErrInvalidLoginOrPassword
+		}
+		return nil, toStatus(err)
+	}
+	return &pb.TokenResponse{Token: token.Token}, nil
+}
+
+func (h *Handler) createToken(ctx context.Context, user *domain.UserIn) (*pb.TokenResponse, error) {
+	token, err := h.service.CreateToken(ctx, user)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.TokenResponse{
+		Token:       token.Token,
+		MfaRequired: token.MFARequired,
+		MfaToken:    token.MFAToken,
+	}, nil
+}
+
+func (h *Handler) UploadOrder(ctx context.Context, req *pb.UploadOrderRequest) (*pb.UploadOrderResponse, error) {
+	userID, err := getUserID(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if req.GetNumber() == "" {
+		return nil, toStatus(errs.NewFieldError("number", "order number is required"))
+	}
+	err = h.service.CreateOrder(ctx, userID, &domain.OrderIn{Number: req.GetNumber()})
+	switch {
+	case err == nil:
+		return &pb.UploadOrderResponse{}, nil
+	case errors.Is(err, errs.ErrOrderAlreadyAdded):
+		return &pb.UploadOrderResponse{AlreadyUploaded: true}, nil
+	default:
+		return nil, toStatus(err)
+	}
+}
+
+func (h *Handler) ListOrders(ctx context.Context, req *pb.ListOrdersRequest) (*pb.ListOrdersResponse, error) {
+	userID, err := getUserID(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	query := &domain.OrderQuery{
+		PageQuery: toPageQuery(req.GetPage(), userID),
+		Statuses:  req.GetStatuses(),
+	}
+	if err = validation.ValidateOrderQuery(query); err != nil {
+		return nil, toStatus(err)
+	}
+	page, err := h.service.GetAllOrders(ctx, query)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &pb.ListOrdersResponse{
+		Orders:     make([]*pb.Order, 0, len(page.Orders)),
+		NextCursor: page.NextCursor,
+	}
+	for _, order := range page.Orders {
+		out := &pb.Order{
+			Number:     order.Number,
+			Status:     order.Status,
+			UploadedAt: timestamppb.New(order.UploadedAt),
+		}
+		if order.Accrual != nil {
+			accrual := float64(*order.Accrual)
+			out.Accrual = &accrual
+		}
+		resp.Orders = append(resp.Orders, out)
+	}
+	return resp, nil
+}
+
+func (h *Handler) GetBalance(ctx context.Context, _ *pb.GetBalanceRequest) (*pb.Balance, error) {
+	userID, err := getUserID(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	balance, err := h.service.GetBalance(ctx, userID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.Balance{
+		Current:   float64(balance.Current),
+		Withdrawn: float64(balance.Withdrawn),
+	}, nil
+}
+
+func (h *Handler) Withdraw(ctx context.Context, req *pb.WithdrawRequest) (*pb.WithdrawResponse, error) {
+	userID, err := getUserID(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	withdraw := domain.WithdrawalIn{
+		OrderNumber: req.GetOrder(),
+		Sum:         float32(req.GetSum()),
+		OTPCode:     req.GetOtpCode(),
+	}
+	if err = validation.ValidateWithdrawIn(&withdraw); err != nil {
+		return nil, toStatus(err)
+	}
+	if err = h.service.WithdrawBonuses(ctx, userID, &withdraw); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.WithdrawResponse{}, nil
+}
+
+func (h *Handler) ListWithdrawals(
+	ctx context.Context,
+	req *pb.ListWithdrawalsRequest,
+) (*pb.ListWithdrawalsResponse, error) {
+	userID, err := getUserID(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	query := &domain.WithdrawalQuery{PageQuery: toPageQuery(req.GetPage(), userID)}
+	if err = validation.ValidatePageQuery(&query.PageQuery); err != nil {
+		return nil, toStatus(err)
+	}
+	page, err := h.service.GetAllWithdrawals(ctx, query)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &pb.ListWithdrawalsResponse{
+		Withdrawals: make([]*pb.Withdrawal, 0, len(page.Withdrawals)),
+		NextCursor:  page.NextCursor,
+	}
+	for _, withdrawal := range page.Withdrawals {
+		resp.Withdrawals = append(resp.Withdrawals, &pb.Withdrawal{
+			Order:       withdrawal.Order,
+			Sum:         float64(withdrawal.Sum),
+			ProcessedAt: timestamppb.New(withdrawal.ProcessedAt),
+		})
+	}
+	return resp, nil
+}
+
+func toPageQuery(page *pb.PageRequest, userID int) domain.PageQuery {
+	query := domain.PageQuery{
+		UserID: userID,
+		Limit:  int(page.GetLimit()),
+		Cursor: page.GetCursor(),
+		Sort:   page.GetSort(),
+	}
+	if page.GetFrom() != nil {
+		from := page.GetFrom().AsTime()
+		query.From = &from
+	}
+	if page.GetTo() != nil {
+		to := page.GetTo().AsTime()
+		query.To = &to
+	}
+	return query
+}
+
+func getUserID(ctx context.Context) (int, error) {
+	principal, ok := domain.PrincipalFromContext(ctx)
+	if !ok {
+		return 0, errs.ErrUnauthorized
+	}
+	return principal.UserID, nil
+}
@@ -0,0 +1,192 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"gophermart/internal/adapters/api/apierr"
+	"gophermart/internal/adapters/api/grpcapi/pb"
+	"gophermart/internal/adapters/ratelimit"
+	"gophermart/internal/core/domain"
+	"gophermart/internal/errs"
+	"gophermart/internal/logger"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	authorization = "authorization"
+	apiKeyHeader  = "x-api-key"
+)
+
+// publicMethods run without credentials.
+var publicMethods = map[string]bool{
+	pb.Gophermart_Register_FullMethodName: true,
+	pb.Gophermart_Login_FullMethodName:    true,
+	pb.Gophermart_LoginMFA_FullMethodName: true,
+}
+
+// methodScopes lists the scope each authenticated method requires.
+// Methods in neither map are rejected, so a new method is not public by accident.
+var methodScopes = map[string]string{
+	pb.Gophermart_UploadOrder_FullMethodName:     domain.ScopeOrdersWrite,
+	pb.Gophermart_ListOrders_FullMethodName:      domain.ScopeOrdersRead,
+	pb.Gophermart_GetBalance_FullMethodName:      domain.ScopeBalanceRead,
+	pb.Gophermart_Withdraw_FullMethodName:        domain.ScopeBalanceWrite,
+	pb.Gophermart_ListWithdrawals_FullMethodName: domain.ScopeBalanceRead,
+}
+
+// rateLimitedMethods take from the sign-in bucket of the client, the same one the REST sign-in routes use.
+var rateLimitedMethods = map[string]bool{
+	pb.Gophermart_Register_FullMethodName: true,
+	pb.Gophermart_Login_FullMethodName:    true,
+	pb.Gophermart_LoginMFA_FullMethodName: true,
+}
+
+func loggingInterceptor(
+	ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	logger.Log.Info("got incoming grpc request",
+		zap.String("method", info.FullMethod),
+		zap.String("code", status.Code(err).String()),
+		zap.String("duration", time.Since(start).String()),
+	)
+	return resp, err
+}
+
+func (h *Handler) authInterceptor(
+	ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	if publicMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+	scope, ok := methodScopes[info.FullMethod]
+	if !ok {
+		logger.Log.Error("grpc method has no scope", zap.String("method", info.FullMethod))
+		return nil, toStatus(fmt.Errorf("%w: method %s has no scope", errs.ErrForbidden, info.FullMethod))
+	}
+	principal, err := h.authenticate(ctx)
+	if err != nil {
+		return nil, toStatus(fmt.Errorf("%w: %w", errs.ErrUnauthorized, err))
+	}
+	if err = h.certs.CheckPartnerCert(principal, connectionState(ctx)); err != nil {
+		return nil, toStatus(err)
+	}
+	if !principal.HasScope(scope) {
+		return nil, toStatus(fmt.Errorf("%w: missing scope %s", errs.ErrForbidden, scope))
+	}
+	return handler(domain.WithPrincipal(ctx, principal), req)
+}
+
+// rateLimitInterceptor applies the REST sign-in limit to rateLimitedMethods. The remaining time is sent
+// in the retry-after header.
+func (h *Handler) rateLimitInterceptor(
+	ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	limit := h.config.RateLimitAuth
+	window := time.Duration(h.config.RateLimitWindow) * time.Second
+	if !rateLimitedMethods[info.FullMethod] || h.limiter == nil || limit <= 0 || window <= 0 {
+		return handler(ctx, req)
+	}
+	result, err := h.limiter.Take(ctx, ratelimit.GroupAuth+":ip:"+h.clientIP(ctx), limit, window)
+	if err != nil {
+		// An unavailable store must not take the API down with it.
+		logger.Log.Error("failed to check rate limit", zap.String("method", info.FullMethod), zap.Error(err))
+		return handler(ctx, req)
+	}
+	if !result.Allowed {
+		reset := strconv.Itoa(int(math.Ceil(time.Until(result.Reset).Seconds())))
+		if err = grpc.SetHeader(ctx, metadata.Pairs("retry-after", reset)); err != nil {
+			logger.Log.Error("failed to set retry-after header", zap.Error(err))
+		}
+		return nil, toStatus(errs.ErrTooManyRequests)
+	}
+	return handler(ctx, req)
+}
+
+// clientIP is the peer address or, when configured, the address a trusted proxy passed on in metadata.
+func (h *Handler) clientIP(ctx context.Context) string {
+	var address, forwarded string
+	if p, ok := peer.FromContext(ctx); ok {
+		address = p.Addr.String()
+	}
+	if h.config.RateLimitIPHeader != "" {
+		md, _ := metadata.FromIncomingContext(ctx)
+		forwarded = firstValue(md, strings.ToLower(h.config.RateLimitIPHeader))
+	}
+	return ratelimit.ClientIP(address, forwarded)
+}
+
+func (h *Handler) authenticate(ctx context.Context) (*domain.Principal, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	accessToken := strings.TrimPrefix(firstValue(md, authorization), "Bearer ")
+	apiKey := firstValue(md, apiKeyHeader)
+	if apiKey == "" && strings.HasPrefix(accessToken, domain.APIKeyPrefix) {
+		apiKey = accessToken
+	}
+	switch {
+	case apiKey != "":
+		return h.service.ParseAPIKey(ctx, apiKey) //nolint:wrapcheck // wrapped by the caller
+	case accessToken != "":
+		return h.service.ParseToken(accessToken) //nolint:wrapcheck // wrapped by the caller
+	default:
+		return nil, errors.New("missing credentials")
+	}
+}
+
+func connectionState(ctx context.Context) *tls.ConnectionState {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+		return &info.State
+	}
+	return nil
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// toStatus converts a service error into a gRPC status using the shared error mapping. Like the REST
+// problem details, the message is the sentinel's text plus any field errors, never the wrapped chain.
+func toStatus(err error) error {
+	m, known := apierr.Lookup(err)
+	if !known {
+		logger.Log.Error("unexpected error occurred", zap.Error(err))
+		return status.Error(codes.Internal, m.Code) //nolint:wrapcheck // status errors must not be wrapped
+	}
+	logger.Log.Info("grpc request failed", zap.Error(err))
+	message := m.Err.Error()
+	var validationErr *errs.ValidationError
+	if errors.As(err, &validationErr) {
+		message = validationErr.Error()
+	}
+	return status.Error(m.GRPC, message) //nolint:wrapcheck // status errors must not be wrapped
+}
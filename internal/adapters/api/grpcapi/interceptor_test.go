@@ -0,0 +1,40 @@
+package grpcapi
+
+import (
+	"context"
+	"gophermart/internal/adapters/api/grpcapi/pb"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestEveryMethodIsClassified fails when a method is added to the service without deciding
+// whether it is public or which scope it requires.
+func TestEveryMethodIsClassified(t *testing.T) {
+	for _, method := range pb.Gophermart_ServiceDesc.Methods {
+		fullMethod := "/" + pb.Gophermart_ServiceDesc.ServiceName + "/" + method.MethodName
+		_, scoped := methodScopes[fullMethod]
+		if publicMethods[fullMethod] == scoped {
+			t.Errorf("%s must be either public or have a scope", fullMethod)
+		}
+	}
+}
+
+func TestAuthInterceptorRejectsUnknownMethods(t *testing.T) {
+	h := &Handler{}
+	called := false
+	handler := func(context.Context, any) (any, error) {
+		called = true
+		return struct{}{}, nil
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/gophermart.v1.Gophermart/Unlisted"}
+	_, err := h.authInterceptor(context.Background(), nil, info, handler)
+	if code := status.Code(err); code != codes.PermissionDenied {
+		t.Errorf("code = %s, want %s", code, codes.PermissionDenied)
+	}
+	if called {
+		t.Error("handler of an unlisted method was called")
+	}
+}
@@ -0,0 +1,142 @@
+package rest
+
+import (
+	"gophermart/internal/adapters/api/validation"
+	"gophermart/internal/core/domain"
+	"gophermart/internal/errs"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *Handler) AdminFindUser(w http.ResponseWriter, req *http.Request) {
+	adminID, err := getUserID(req)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	login := req.URL.Query().Get("login")
+	if login == "" {
+		renderError(w, req, errs.NewFieldError("login", "is required"))
+		return
+	}
+	user, err := h.service.FindUserByLogin(req.Context(), adminID, login)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	writeJSON(w, user)
+}
+
+func (h *Handler) AdminGetUser(w http.ResponseWriter, req *http.Request) {
+	adminID, userID, ok := getAdminAndTargetUserID(w, req)
+	if !ok {
+		return
+	}
+	user, err := h.service.GetUser(req.Context(), adminID, userID)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	writeJSON(w, user)
+}
+
+func (h *Handler) AdminGetUserOrders(w http.ResponseWriter, req *http.Request) {
+	adminID, userID, ok := getAdminAndTargetUserID(w, req)
+	if !ok {
+		return
+	}
+	query, err := parseOrderQuery(req, userID)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	page, err := h.service.GetUserOrders(req.Context(), adminID, query)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	setNextLink(w, req, page.NextCursor)
+	writeJSON(w, page.Orders)
+}
+
+func (h *Handler) AdminGetUserWithdrawals(w http.ResponseWriter, req *http.Request) {
+	adminID, userID, ok := getAdminAndTargetUserID(w, req)
+	if !ok {
+		return
+	}
+	query, err := parseWithdrawalQuery(req, userID)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	page, err := h.service.GetUserWithdrawals(req.Context(), adminID, query)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	setNextLink(w, req, page.NextCursor)
+	writeJSON(w, page.Withdrawals)
+}
+
+func (h *Handler) AdminGetUserBalance(w http.ResponseWriter, req *http.Request) {
+	adminID, userID, ok := getAdminAndTargetUserID(w, req)
+	if !ok {
+		return
+	}
+	balance, err := h.service.GetUserBalance(req.Context(), adminID, userID)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	writeJSON(w, balance)
+}
+
+func (h *Handler) AdminAdjustBalance(w http.ResponseWriter, req *http.Request) {
+	var adjustment domain.BalanceAdjustmentIn
+	adminID, userID, ok := getAdminAndTargetUserID(w, req)
+	if !ok {
+		return
+	}
+	if err := decodeJSON(req, &adjustment); err != nil {
+		renderError(w, req, err)
+		return
+	}
+	if err := validation.ValidateBalanceAdjustmentIn(&adjustment); err != nil {
+		renderError(w, req, err)
+		return
+	}
+	if err := h.service.AdjustBalance(req.Context(), adminID, userID, &adjustment); err != nil {
+		renderError(w, req, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *Handler) AdminRequeueOrder(w http.ResponseWriter, req *http.Request) {
+	adminID, err := getUserID(req)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	if err = h.service.RequeueOrder(req.Context(), adminID, chi.URLParam(req, "number")); err != nil {
+		renderError(w, req, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func getAdminAndTargetUserID(w http.ResponseWriter, req *http.Request) (int, int, bool) {
+	adminID, err := getUserID(req)
+	if err != nil {
+		renderError(w, req, err)
+		return 0, 0, false
+	}
+	userID, err := strconv.Atoi(chi.URLParam(req, "userID"))
+	if err != nil {
+		renderError(w, req, errs.NewFieldError("userID", "must be an integer"))
+		return 0, 0, false
+	}
+	return adminID, userID, true
+}
@@ -0,0 +1,77 @@
+package rest
+
+import (
+	"encoding/json"
+	"gophermart/internal/adapters/api/validation"
+	"gophermart/internal/core/domain"
+	"gophermart/internal/errs"
+	"gophermart/internal/logger"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+func (h *Handler) CreateAPIKey(w http.ResponseWriter, req *http.Request) {
+	var keyIn domain.APIKeyIn
+	userID, err := getUserID(req)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	if err = decodeJSON(req, &keyIn); err != nil {
+		renderError(w, req, err)
+		return
+	}
+	if err = validation.ValidateAPIKeyIn(&keyIn); err != nil {
+		renderError(w, req, err)
+		return
+	}
+	key, err := h.service.CreateAPIKey(req.Context(), userID, &keyIn)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	w.Header().Set(contentType, applicationJSON)
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(key); err != nil {
+		logger.FromContext(req.Context()).Error("error encoding api key", zap.Error(err))
+	}
+}
+
+func (h *Handler) GetAllAPIKeys(w http.ResponseWriter, req *http.Request) {
+	userID, err := getUserID(req)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	keys, err := h.service.GetAllAPIKeys(req.Context(), userID)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	if len(keys) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, keys)
+}
+
+func (h *Handler) RevokeAPIKey(w http.ResponseWriter, req *http.Request) {
+	userID, err := getUserID(req)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	keyID, err := strconv.Atoi(chi.URLParam(req, "keyID"))
+	if err != nil {
+		renderError(w, req, errs.NewFieldError("keyID", "must be an integer"))
+		return
+	}
+	if err = h.service.RevokeAPIKey(req.Context(), userID, keyID); err != nil {
+		renderError(w, req, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -15,61 +15,74 @@ import (
 
 func (h *Handler) SignUp(w http.ResponseWriter, req *http.Request) {
 	var user domain.UserIn
-	if err := json.NewDecoder(req.Body).Decode(&user); err != nil {
-		logger.Log.Info("cannot decode userIn JSON body", zap.Error(err))
-		w.WriteHeader(http.StatusBadRequest)
+	if err := decodeJSON(req, &user); err != nil {
+		renderError(w, req, err)
 		return
 	}
 	if err := validation.ValidateUserIn(&user); err != nil {
-		logger.Log.Info("userIn validation error", zap.Error(err))
-		w.WriteHeader(http.StatusBadRequest)
+		renderError(w, req, err)
 		return
 	}
 	if err := h.service.CreateUser(req.Context(), &user); err != nil {
-		handleAuthError(w, err)
+		renderError(w, req, err)
 		return
 	}
 	h.createToken(w, req, &user)
-	w.WriteHeader(http.StatusOK)
 }
 
 func (h *Handler) SignIn(w http.ResponseWriter, req *http.Request) {
 	var user domain.UserIn
-	if err := json.NewDecoder(req.Body).Decode(&user); err != nil {
-		logger.Log.Info("cannot decode userIn JSON body", zap.Error(err))
-		w.WriteHeader(http.StatusBadRequest)
+	if err := decodeJSON(req, &user); err != nil {
+		renderError(w, req, err)
 		return
 	}
 	if err := validation.ValidateUserIn(&user); err != nil {
-		logger.Log.Info("userIn validation error", zap.Error(err))
-		w.WriteHeader(http.StatusBadRequest)
+		renderError(w, req, err)
 		return
 	}
 	h.createToken(w, req, &user)
 }
 
+func (h *Handler) SignInMFA(w http.ResponseWriter, req *http.Request) {
+	var mfaIn domain.MFALoginIn
+	if err := decodeJSON(req, &mfaIn); err != nil {
+		renderError(w, req, err)
+		return
+	}
+	if err := validation.ValidateMFALoginIn(&mfaIn); err != nil {
+		renderError(w, req, err)
+		return
+	}
+	token, err := h.service.CompleteMFALogin(req.Context(), &mfaIn)
+	if err != nil {
+		if !errors.Is(err, errs.ErrInvalidSecondFactor) {
+			logger.FromContext(req.Context()).Info("mfa login failed", zap.Error(err))
+			err = errs.ErrInvalidLoginOrPassword
+		}
+		renderError(w, req, err)
+		return
+	}
+	writeToken(w, token)
+}
+
 func (h *Handler) createToken(w http.ResponseWriter, req *http.Request, user *domain.UserIn) {
 	token, err := h.service.CreateToken(req.Context(), user)
 	if err != nil {
-		handleAuthError(w, err)
+		renderError(w, req, err)
 		return
 	}
-	w.Header().Set(authorization, fmt.Sprintf("Bearer %s", token))
-	w.Header().Set(contentType, applicationJSON)
-	if err = json.NewEncoder(w).Encode(domain.Token{Token: token}); err != nil {
-		logger.Log.Error("error encoding token", zap.Error(err))
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+	if token.MFARequired {
+		w.Header().Set(contentType, applicationJSON)
+		w.WriteHeader(http.StatusAccepted)
+		if err = json.NewEncoder(w).Encode(token); err != nil {
+			logger.FromContext(req.Context()).Error("error encoding mfa token", zap.Error(err))
+		}
+		return
 	}
+	writeToken(w, token)
 }
 
-func handleAuthError(w http.ResponseWriter, err error) {
-	statusCode := http.StatusInternalServerError
-	if errors.Is(err, errs.ErrLoginAlreadyExist) {
-		statusCode = http.StatusConflict
-	}
-	if errors.Is(err, errs.ErrInvalidLoginOrPassword) {
-		statusCode = http.StatusUnauthorized
-	}
-	logger.Log.Error("error occurred", zap.Error(err))
-	http.Error(w, http.StatusText(statusCode), statusCode)
+func writeToken(w http.ResponseWriter, token *domain.Token) {
+	w.Header().Set(authorization, fmt.Sprintf("Bearer %s", token.Token))
+	writeJSON(w, token)
 }
@@ -0,0 +1,119 @@
+package rest
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"gophermart/internal/adapters/api/validation"
+	"gophermart/internal/errs"
+	"io"
+	"net/http"
+	"strings"
+	"unicode"
+)
+
+const (
+	textPlain = "text/plain"
+	textCSV   = "text/csv"
+)
+
+func (h *Handler) CreateOrders(w http.ResponseWriter, req *http.Request) {
+	userID, err := getUserID(req)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	numbers, err := parseOrderNumbers(req)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	if err = validation.ValidateBulkOrders(numbers); err != nil {
+		renderError(w, req, err)
+		return
+	}
+	results, err := h.service.CreateOrders(req.Context(), userID, numbers)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	writeJSON(w, results)
+}
+
+// parseOrderNumbers reads a newline separated list, a CSV with numbers in the first column
+// or a JSON array of numbers, depending on the request Content-Type.
+func parseOrderNumbers(req *http.Request) ([]string, error) {
+	mediaType, err := requireContentType(req, textPlain, textCSV, applicationJSON)
+	if err != nil {
+		return nil, err
+	}
+	var numbers []string
+	switch mediaType {
+	case textPlain:
+		numbers, err = parsePlainNumbers(req.Body)
+	case textCSV:
+		numbers, err = parseCSVNumbers(req.Body)
+	default:
+		numbers, err = parseJSONNumbers(req.Body)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errs.ErrMalformedBody, err)
+	}
+	return numbers, nil
+}
+
+func parsePlainNumbers(body io.Reader) ([]string, error) {
+	var numbers []string
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		if number := strings.TrimSpace(scanner.Text()); number != "" {
+			numbers = append(numbers, number)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read order numbers: %w", err)
+	}
+	return numbers, nil
+}
+
+// parseCSVNumbers takes the first column and skips a leading header row without digits.
+func parseCSVNumbers(body io.Reader) ([]string, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv: %w", err)
+	}
+	numbers := make([]string, 0, len(records))
+	for i, record := range records {
+		number := strings.TrimSpace(record[0])
+		if number == "" || (i == 0 && strings.IndexFunc(number, unicode.IsDigit) < 0) {
+			continue
+		}
+		numbers = append(numbers, number)
+	}
+	return numbers, nil
+}
+
+// parseJSONNumbers accepts both strings and bare JSON numbers as array items.
+func parseJSONNumbers(body io.Reader) ([]string, error) {
+	var items []json.RawMessage
+	if err := decodeStrict(body, &items); err != nil {
+		return nil, fmt.Errorf("failed to decode json array: %w", err)
+	}
+	numbers := make([]string, 0, len(items))
+	for _, item := range items {
+		var number string
+		if err := json.Unmarshal(item, &number); err != nil {
+			var value json.Number
+			if err = json.Unmarshal(item, &value); err != nil {
+				return nil, fmt.Errorf("order number must be a string or a number: %w", err)
+			}
+			number = value.String()
+		}
+		numbers = append(numbers, strings.TrimSpace(number))
+	}
+	return numbers, nil
+}
@@ -0,0 +1,298 @@
+package rest
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"gophermart/internal/errs"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	acceptEncoding  = "Accept-Encoding"
+	contentEncoding = "Content-Encoding"
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate" // the zlib format of RFC 1950, not a raw deflate stream
+	identity        = "identity"
+)
+
+// compressibleTypes lists the media types worth compressing; everything else, including event streams, passes through.
+var compressibleTypes = []string{
+	applicationJSON,
+	applicationProblemJSON,
+	textPlain,
+	textCSV,
+	applicationPDF,
+}
+
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// encoders are listed in the server's order of preference, which breaks ties between equal q-values.
+var encoders = []struct {
+	name string
+	pool *sync.Pool
+}{
+	{encodingGzip, &sync.Pool{New: func() any {
+		w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+		return w
+	}}},
+	{encodingDeflate, &sync.Pool{New: func() any {
+		w, _ := zlib.NewWriterLevel(io.Discard, zlib.DefaultCompression)
+		return w
+	}}},
+}
+
+// negotiateEncoding picks the preferred supported coding from Accept-Encoding, or "" for identity.
+func negotiateEncoding(header string) (string, *sync.Pool) {
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if name == "" {
+			continue
+		}
+		weight := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			weight = parsed
+		}
+		weights[strings.ToLower(name)] = weight
+	}
+	var (
+		best     string
+		bestPool *sync.Pool
+		bestQ    float64
+	)
+	for _, e := range encoders {
+		q, ok := weights[e.name]
+		if !ok {
+			q = weights["*"]
+		}
+		if q > bestQ {
+			best, bestPool, bestQ = e.name, e.pool, q
+		}
+	}
+	return best, bestPool
+}
+
+// compressMiddleware compresses responses of allowed types once they reach minSize bytes.
+// Responses flushed before that, such as streamed exports, are compressed regardless of size.
+func (h *Handler) compressMiddleware(minSize int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		compressFn := func(w http.ResponseWriter, r *http.Request) {
+			encoding, pool := negotiateEncoding(r.Header.Get(acceptEncoding))
+			if encoding == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+			cw := &compressResponseWriter{
+				ResponseWriter: w,
+				encoding:       encoding,
+				pool:           pool,
+				minSize:        minSize,
+				status:         http.StatusOK,
+			}
+			next.ServeHTTP(cw, r)
+			// A panicking handler skips this on purpose: closing the encoder would make
+			// an aborted response look complete.
+			cw.close()
+		}
+		return http.HandlerFunc(compressFn)
+	}
+}
+
+type compressResponseWriter struct {
+	http.ResponseWriter
+	encoding string
+	pool     *sync.Pool
+	minSize  int
+	status   int
+	buf      []byte
+	decided  bool
+	enc      encoder
+}
+
+func (c *compressResponseWriter) WriteHeader(statusCode int) {
+	if c.decided || statusCode < http.StatusOK {
+		c.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	c.status = statusCode
+}
+
+func (c *compressResponseWriter) Write(p []byte) (int, error) {
+	if !c.decided {
+		c.buf = append(c.buf, p...)
+		if len(c.buf) < c.minSize {
+			return len(p), nil
+		}
+		if err := c.decide(false); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if c.enc != nil {
+		n, err := c.enc.Write(p)
+		if err != nil {
+			return n, fmt.Errorf("failed to compress response: %w", err)
+		}
+		return n, nil
+	}
+	n, err := c.ResponseWriter.Write(p)
+	if err != nil {
+		return n, fmt.Errorf("failed to write response: %w", err)
+	}
+	return n, nil
+}
+
+// Flush commits to a decision early so streamed responses reach the client as they are produced.
+func (c *compressResponseWriter) Flush() {
+	if !c.decided {
+		_ = c.decide(true)
+	}
+	if c.enc != nil {
+		_ = c.enc.Flush()
+	}
+	_ = http.NewResponseController(c.ResponseWriter).Flush()
+}
+
+func (c *compressResponseWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// decide sends the headers and the buffered bytes, switching to the encoder if the response qualifies.
+func (c *compressResponseWriter) decide(flushing bool) error {
+	c.decided = true
+	header := c.Header()
+	if header.Get(contentType) == "" && len(c.buf) > 0 {
+		header.Set(contentType, http.DetectContentType(c.buf))
+	}
+	if c.compressible() {
+		header.Add("Vary", acceptEncoding)
+		if flushing || len(c.buf) >= c.minSize {
+			header.Set(contentEncoding, c.encoding)
+			header.Del("Content-Length")
+			// The compressed body is a different representation, so a strong validator no longer holds.
+			if etag := header.Get("ETag"); strings.HasPrefix(etag, `"`) {
+				header.Set("ETag", "W/"+etag)
+			}
+			c.enc, _ = c.pool.Get().(encoder)
+			c.enc.Reset(c.ResponseWriter)
+		}
+	}
+	c.ResponseWriter.WriteHeader(c.status)
+	if len(c.buf) == 0 {
+		return nil
+	}
+	var err error
+	if c.enc != nil {
+		_, err = c.enc.Write(c.buf)
+	} else {
+		_, err = c.ResponseWriter.Write(c.buf)
+	}
+	c.buf = nil
+	if err != nil {
+		return fmt.Errorf("failed to write response: %w", err)
+	}
+	return nil
+}
+
+func (c *compressResponseWriter) compressible() bool {
+	if c.status == http.StatusNoContent || c.status == http.StatusNotModified {
+		return false
+	}
+	header := c.Header()
+	if header.Get(contentEncoding) != "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(header.Get(contentType))
+	return err == nil && slices.Contains(compressibleTypes, mediaType)
+}
+
+func (c *compressResponseWriter) close() {
+	if !c.decided {
+		_ = c.decide(false)
+	}
+	if c.enc == nil {
+		return
+	}
+	_ = c.enc.Close()
+	c.enc.Reset(io.Discard)
+	c.pool.Put(c.enc)
+	c.enc = nil
+}
+
+// decompressMiddleware transparently inflates gzip and deflate (zlib) request bodies,
+// failing the read once more than limit bytes come out.
+func (h *Handler) decompressMiddleware(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		decompressFn := func(w http.ResponseWriter, r *http.Request) {
+			var (
+				body io.ReadCloser
+				err  error
+			)
+			switch encoding := strings.ToLower(strings.TrimSpace(r.Header.Get(contentEncoding))); encoding {
+			case "", identity:
+				next.ServeHTTP(w, r)
+				return
+			case encodingGzip, "x-gzip":
+				body, err = gzip.NewReader(r.Body)
+			case encodingDeflate:
+				body, err = zlib.NewReader(r.Body)
+			default:
+				renderError(w, r, fmt.Errorf("%w: content encoding %s", errs.ErrUnsupportedMediaType, encoding))
+				return
+			}
+			if err != nil {
+				renderError(w, r, fmt.Errorf("%w: %w", errs.ErrMalformedBody, err))
+				return
+			}
+			defer body.Close()
+			r.Body = &limitedBody{ReadCloser: body, remaining: limit, limit: limit}
+			r.Header.Del(contentEncoding)
+			r.Header.Del("Content-Length")
+			r.ContentLength = -1
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(decompressFn)
+	}
+}
+
+// limitedBody caps how many bytes handlers can read, guarding against oversized bodies and decompression bombs.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	limit     int64
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, fmt.Errorf("%w: body exceeds %d bytes", errs.ErrPayloadTooLarge, l.limit)
+	}
+	// Reading one byte past the limit tells a body of exactly limit bytes from a larger one.
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.ReadCloser.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n + int(l.remaining), fmt.Errorf("%w: body exceeds %d bytes", errs.ErrPayloadTooLarge, l.limit)
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return n, fmt.Errorf("failed to read body: %w", err)
+	}
+	return n, err //nolint:wrapcheck // io.EOF must reach readers unwrapped
+}
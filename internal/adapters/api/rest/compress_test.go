@@ -0,0 +1,96 @@
+package rest
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var compressPayload = strings.Repeat(`{"number":"4561261212345467","status":"PROCESSED"}`, 64)
+
+// TestDeflateResponseIsZlib decodes a deflate response with a real zlib reader, as HTTP clients do.
+func TestDeflateResponseIsZlib(t *testing.T) {
+	h := &Handler{}
+	handler := h.compressMiddleware(1)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set(contentType, applicationJSON)
+		_, _ = io.WriteString(w, compressPayload)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/api/user/orders", http.NoBody)
+	req.Header.Set(acceptEncoding, encodingDeflate)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get(contentEncoding); got != encodingDeflate {
+		t.Fatalf("Content-Encoding = %q, want %q", got, encodingDeflate)
+	}
+	reader, err := zlib.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("response is not zlib: %v", err)
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("failed to inflate response: %v", err)
+	}
+	if string(body) != compressPayload {
+		t.Errorf("inflated body differs from the payload")
+	}
+}
+
+// TestDeflateRequestIsZlib sends a body compressed by a real zlib writer and rejects a raw deflate stream.
+func TestDeflateRequestIsZlib(t *testing.T) {
+	tests := []struct {
+		name       string
+		compress   func(w io.Writer) io.WriteCloser
+		wantStatus int
+	}{
+		{
+			name:       "zlib",
+			compress:   func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "raw deflate",
+			compress: func(w io.Writer) io.WriteCloser {
+				fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+				return fw
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var compressed bytes.Buffer
+			cw := tt.compress(&compressed)
+			_, _ = io.WriteString(cw, compressPayload)
+			if err := cw.Close(); err != nil {
+				t.Fatalf("failed to compress payload: %v", err)
+			}
+			h := &Handler{}
+			var got string
+			handler := h.decompressMiddleware(1 << 20)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				if err != nil {
+					renderError(w, r, err)
+					return
+				}
+				got = string(body)
+			}))
+			req := httptest.NewRequest(http.MethodPost, "/api/user/orders", &compressed)
+			req.Header.Set(contentEncoding, encodingDeflate)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus == http.StatusOK && got != compressPayload {
+				t.Errorf("handler read a body that differs from the payload")
+			}
+		})
+	}
+}
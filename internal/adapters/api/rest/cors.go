@@ -0,0 +1,122 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"gophermart/internal/config"
+	"gophermart/internal/errs"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// corsExposedHeaders are the response headers browser clients need to read.
+var corsExposedHeaders = strings.Join([]string{
+	authorization,
+	"ETag",
+	"Link",
+	"Retry-After",
+	"RateLimit-Limit",
+	"RateLimit-Remaining",
+	"RateLimit-Reset",
+	"RateLimit-Policy",
+	requestIDHeader,
+	idempotentReplayed,
+}, ", ")
+
+var errCORSWildcardCredentials = errors.New("cors: the * origin cannot be combined with credentials")
+
+type corsPolicy struct {
+	origins     []string
+	anyOrigin   bool
+	methods     []string
+	headers     string
+	credentials bool
+	maxAge      string
+}
+
+func newCORSPolicy(cfg *config.Config) (*corsPolicy, error) {
+	p := &corsPolicy{
+		origins:     splitList(cfg.CORSOrigins),
+		methods:     splitList(strings.ToUpper(cfg.CORSMethods)),
+		headers:     strings.Join(splitList(cfg.CORSHeaders), ", "),
+		credentials: cfg.CORSCredentials,
+		maxAge:      strconv.Itoa(cfg.CORSMaxAge),
+	}
+	p.anyOrigin = slices.Contains(p.origins, "*")
+	if p.anyOrigin && p.credentials {
+		return nil, errCORSWildcardCredentials
+	}
+	return p, nil
+}
+
+func (p *corsPolicy) enabled() bool {
+	return len(p.origins) > 0
+}
+
+func (p *corsPolicy) allowOrigin(origin string) bool {
+	return p.anyOrigin || slices.Contains(p.origins, origin)
+}
+
+// corsMiddleware answers preflights itself and marks actual responses readable for allowed origins.
+// Requests from other origins get no CORS headers, so the browser keeps blocking them.
+func (h *Handler) corsMiddleware(policy *corsPolicy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		corsFn := func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			w.Header().Add("Vary", "Origin")
+			if origin == "" || !policy.allowOrigin(origin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if preflight {
+				policy.preflight(w, r, origin)
+				return
+			}
+			policy.setOrigin(w, origin)
+			w.Header().Set("Access-Control-Expose-Headers", corsExposedHeaders)
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(corsFn)
+	}
+}
+
+func (p *corsPolicy) preflight(w http.ResponseWriter, r *http.Request, origin string) {
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+	method := r.Header.Get("Access-Control-Request-Method")
+	if !slices.Contains(p.methods, method) {
+		renderError(w, r, fmt.Errorf("%w: cors method %s is not allowed", errs.ErrForbidden, method))
+		return
+	}
+	p.setOrigin(w, origin)
+	w.Header().Set("Access-Control-Allow-Methods", strings.Join(p.methods, ", "))
+	if p.headers != "" {
+		w.Header().Set("Access-Control-Allow-Headers", p.headers)
+	}
+	w.Header().Set("Access-Control-Max-Age", p.maxAge)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (p *corsPolicy) setOrigin(w http.ResponseWriter, origin string) {
+	if p.anyOrigin {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	if p.credentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,143 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gophermart/internal/core/domain"
+	"gophermart/internal/errs"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// Body limits per route kind. maxRequestBody applies to every request and is tightened per route.
+const (
+	maxRequestBody = 1 << 20
+	maxJSONBody    = 64 << 10
+	maxOrderBody   = 128
+	maxBatchBody   = domain.MaxBulkOrders * 64
+)
+
+// bodyLimitMiddleware caps how much of the request body handlers can read, rejecting a declared
+// Content-Length above the limit right away. Nested limits stack, so the tightest one wins.
+func (h *Handler) bodyLimitMiddleware(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		limitFn := func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				renderError(w, r, fmt.Errorf("%w: body exceeds %d bytes", errs.ErrPayloadTooLarge, limit))
+				return
+			}
+			r.Body = &limitedBody{ReadCloser: r.Body, remaining: limit, limit: limit}
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(limitFn)
+	}
+}
+
+// requireContentType returns the request media type if it is one of allowed.
+func requireContentType(req *http.Request, allowed ...string) (string, error) {
+	header := req.Header.Get(contentType)
+	if header == "" {
+		return "", fmt.Errorf("%w: Content-Type is required, use %s", errs.ErrUnsupportedMediaType,
+			strings.Join(allowed, " or "))
+	}
+	mediaType, _, err := mime.ParseMediaType(header)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errs.ErrUnsupportedMediaType, err)
+	}
+	for _, a := range allowed {
+		if mediaType == a {
+			return mediaType, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s, use %s", errs.ErrUnsupportedMediaType, mediaType, strings.Join(allowed, " or "))
+}
+
+// decodeJSON requires an application/json body holding exactly one JSON value without unknown fields.
+func decodeJSON(req *http.Request, v any) error {
+	if _, err := requireContentType(req, applicationJSON); err != nil {
+		return err
+	}
+	return decodeStrict(req.Body, v)
+}
+
+func decodeStrict(body io.Reader, v any) error {
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return jsonError(err)
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		if errors.Is(err, errs.ErrPayloadTooLarge) {
+			return err
+		}
+		return errs.NewFieldError("body", "must contain a single JSON value")
+	}
+	return nil
+}
+
+// jsonError turns decoder errors into field errors where the field is known.
+func jsonError(err error) error {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.Is(err, errs.ErrPayloadTooLarge):
+		return err
+	case errors.Is(err, io.EOF):
+		return errs.NewFieldError("body", "is required")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return fmt.Errorf("%w: unexpected end of JSON", errs.ErrMalformedBody)
+	case errors.As(err, &syntaxErr):
+		return fmt.Errorf("%w: %s at offset %d", errs.ErrMalformedBody, syntaxErr.Error(), syntaxErr.Offset)
+	case errors.As(err, &typeErr):
+		field := typeErr.Field
+		if field == "" {
+			field = "body"
+		}
+		return errs.NewFieldError(field, "must be "+jsonType(typeErr.Type)+", got "+typeErr.Value)
+	}
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return errs.NewFieldError(strings.Trim(field, `"`), "unknown field")
+	}
+	return fmt.Errorf("%w: %w", errs.ErrMalformedBody, err)
+}
+
+// jsonType names a Go type the way it is spelled in JSON.
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.String:
+		return "a string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
+
+// readText reads a text/plain body and trims surrounding whitespace.
+func readText(req *http.Request) (string, error) {
+	if _, err := requireContentType(req, textPlain); err != nil {
+		return "", err
+	}
+	text, err := io.ReadAll(req.Body)
+	if err != nil {
+		if errors.Is(err, errs.ErrPayloadTooLarge) {
+			return "", err
+		}
+		return "", fmt.Errorf("%w: %w", errs.ErrMalformedBody, err)
+	}
+	return string(bytes.TrimSpace(text)), nil
+}
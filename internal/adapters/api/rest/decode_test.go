@@ -0,0 +1,130 @@
+package rest
+
+import (
+	"encoding/json"
+	"gophermart/internal/core/domain"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// decodeSeeds are bodies every decoder must survive: valid input, malformed JSON, unknown fields,
+// trailing values, wrong types and bodies above the route limits.
+var decodeSeeds = []string{
+	`{"login":"user","password":"secret"}`,
+	`{"login":"user","password":"secret","role":"admin"}`,
+	`{"login":"user"`,
+	`{"login":1}`,
+	`{"login":"user"} {"login":"user"}`,
+	`[]`,
+	`null`,
+	``,
+	"\x00\xff",
+	"4561261212345467",
+	"4561261212345467\n79927398713\n",
+	"number\n4561261212345467,first\n\"79927398713\n",
+	`["4561261212345467", 79927398713, true]`,
+	`[` + strings.Repeat(`"4561261212345467",`, 8<<10) + `"79927398713"]`,
+	strings.Repeat("a", maxJSONBody+1),
+}
+
+var contentTypeSeeds = []string{
+	"application/json",
+	"application/json; charset=utf-8",
+	"text/plain",
+	"text/csv",
+	"application/xml",
+	"",
+	";;",
+}
+
+func FuzzDecodeJSON(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, body, mediaType string, chunked bool) {
+		checkProblem(t, maxJSONBody, body, mediaType, chunked, func(req *http.Request) error {
+			var user domain.UserIn
+			return decodeJSON(req, &user)
+		})
+	})
+}
+
+func FuzzReadText(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, body, mediaType string, chunked bool) {
+		checkProblem(t, maxOrderBody, body, mediaType, chunked, func(req *http.Request) error {
+			_, err := readText(req)
+			return err
+		})
+	})
+}
+
+func FuzzParseOrderNumbers(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, body, mediaType string, chunked bool) {
+		checkProblem(t, maxBatchBody, body, mediaType, chunked, func(req *http.Request) error {
+			_, err := parseOrderNumbers(req)
+			return err
+		})
+	})
+}
+
+func addSeeds(f *testing.F) {
+	f.Helper()
+	for _, body := range decodeSeeds {
+		for _, mediaType := range contentTypeSeeds {
+			f.Add(body, mediaType, false)
+			f.Add(body, mediaType, true)
+		}
+	}
+}
+
+// checkProblem runs decode behind a body limit and requires it to either succeed or render a 400, 413
+// or 415 problem. A chunked body has no declared length, so only reading past the limit stops it.
+func checkProblem(
+	t *testing.T,
+	limit int64,
+	body, mediaType string,
+	chunked bool,
+	decode func(req *http.Request) error,
+) {
+	t.Helper()
+	h := &Handler{}
+	handler := h.bodyLimitMiddleware(limit)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := decode(req); err != nil {
+			renderError(w, req, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/fuzz", strings.NewReader(body))
+	if mediaType != "" {
+		req.Header.Set(contentType, mediaType)
+	}
+	if chunked {
+		req.ContentLength = -1
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if int64(len(body)) > limit && !chunked && rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d for a body of %d bytes above the %d byte limit", rec.Code, len(body), limit)
+	}
+	switch rec.Code {
+	case http.StatusOK:
+		return
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType:
+	default:
+		t.Fatalf("status = %d, want 200, 400, 413 or 415", rec.Code)
+	}
+	if got := rec.Header().Get(contentType); got != applicationProblemJSON {
+		t.Fatalf("Content-Type = %q, want %q", got, applicationProblemJSON)
+	}
+	var p problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatalf("problem body is not JSON: %v", err)
+	}
+	if p.Status != rec.Code {
+		t.Fatalf("problem status = %d, want %d", p.Status, rec.Code)
+	}
+}
@@ -0,0 +1,42 @@
+package rest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const etagLength = 16
+
+// writeJSONWithETag writes v with a strong ETag derived from its encoding,
+// or 304 Not Modified when the request already holds that representation.
+func writeJSONWithETag(w http.ResponseWriter, req *http.Request, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		renderError(w, req, fmt.Errorf("failed to encode response: %w", err))
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:etagLength]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if etagMatches(req.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	writeJSON(w, json.RawMessage(body))
+}
+
+// etagMatches applies the weak comparison RFC 9110 requires for If-None-Match.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"gophermart/internal/core/domain"
+	"gophermart/internal/errs"
+	"gophermart/internal/logger"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	textEventStream = "text/event-stream"
+	lastEventID     = "Last-Event-ID"
+	// reconnectDelay is the retry hint sent to EventSource clients, in milliseconds.
+	reconnectDelay = 3000
+)
+
+// StreamOrderEvents is a Server-Sent Events stream of the caller's order changes.
+// It is served without the request timeout, so it lives until the client disconnects.
+func (h *Handler) StreamOrderEvents(w http.ResponseWriter, req *http.Request) {
+	userID, err := getUserID(req)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	var lastID int64
+	if raw := req.Header.Get(lastEventID); raw != "" {
+		lastID, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil || lastID < 0 {
+			renderError(w, req, errs.NewFieldError(lastEventID, "must be a non-negative integer"))
+			return
+		}
+	}
+	events, err := h.service.SubscribeOrderEvents(req.Context(), userID, lastID)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	rc := http.NewResponseController(w)
+	w.Header().Set(contentType, textEventStream)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if _, err = fmt.Fprintf(w, "retry: %d\n\n", reconnectDelay); err != nil || rc.Flush() != nil {
+		return
+	}
+	heartbeat := time.NewTicker(time.Duration(h.config.EventsHeartbeat) * time.Second)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			err = writeEvent(w, &event)
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(w, ": heartbeat\n\n")
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			logger.FromContext(req.Context()).Info("order events stream closed", zap.Error(err))
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event *domain.OrderEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode order event: %w", err)
+	}
+	if _, err = fmt.Fprintf(w, "id: %d\nevent: order\ndata: %s\n\n", event.ID, data); err != nil {
+		return fmt.Errorf("failed to write order event: %w", err)
+	}
+	return nil
+}
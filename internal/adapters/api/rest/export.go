@@ -0,0 +1,309 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"gophermart/internal/core/domain"
+	"gophermart/internal/errs"
+	"gophermart/internal/logger"
+	"gophermart/internal/shared-kernel/pdf"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	applicationPDF = "application/pdf"
+	formatParam    = "format"
+	exportDate     = time.RFC3339
+	// exportBufferSize is how much of a file is held back before the response is committed.
+	exportBufferSize = 32 << 10
+)
+
+// exportFormat picks csv or pdf from the format parameter or, failing that, the Accept header.
+// An empty format means the regular JSON response.
+func exportFormat(req *http.Request) (string, error) {
+	switch format := strings.ToLower(req.URL.Query().Get(formatParam)); format {
+	case "", "json":
+	case domain.ExportCSV, domain.ExportPDF:
+		return format, nil
+	default:
+		return "", errs.NewFieldError(formatParam, "must be json, csv or pdf")
+	}
+	for _, accepted := range strings.Split(req.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case textCSV:
+			return domain.ExportCSV, nil
+		case applicationPDF:
+			return domain.ExportPDF, nil
+		case applicationJSON:
+			return "", nil
+		}
+	}
+	return "", nil
+}
+
+type column struct {
+	name  string
+	width int
+}
+
+// tableWriter receives export rows one at a time and encodes them straight into the response.
+type tableWriter interface {
+	WriteRow(cells ...string) error
+	Close() error
+}
+
+// exportResponseWriter holds back the start of the file, so that an export failing early can still
+// be answered with a problem instead of a truncated file.
+type exportResponseWriter struct {
+	http.ResponseWriter
+	buf  bytes.Buffer
+	sent bool
+}
+
+func (w *exportResponseWriter) Write(p []byte) (int, error) {
+	if w.sent {
+		return w.write(p)
+	}
+	w.buf.Write(p)
+	if w.buf.Len() >= exportBufferSize {
+		if err := w.flush(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// flush sends what has been held back and passes all further writes through.
+func (w *exportResponseWriter) flush() error {
+	w.sent = true
+	_, err := w.write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+func (w *exportResponseWriter) write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	if err != nil {
+		return n, fmt.Errorf("failed to write export %w", err)
+	}
+	return n, nil
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *exportResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func newTableWriter(w http.ResponseWriter, format, name string, columns []column) tableWriter {
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().UTC().Format("20060102"), format)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	if format == domain.ExportPDF {
+		w.Header().Set(contentType, applicationPDF)
+		return newPDFTableWriter(w, name, columns)
+	}
+	w.Header().Set(contentType, textCSV+"; charset=utf-8")
+	return newCSVTableWriter(w, columns)
+}
+
+type csvTableWriter struct {
+	w *csv.Writer
+}
+
+func newCSVTableWriter(w http.ResponseWriter, columns []column) *csvTableWriter {
+	t := &csvTableWriter{w: csv.NewWriter(w)}
+	names := make([]string, 0, len(columns))
+	for _, c := range columns {
+		names = append(names, c.name)
+	}
+	_ = t.w.Write(names)
+	return t
+}
+
+func (t *csvTableWriter) WriteRow(cells ...string) error {
+	if err := t.w.Write(cells); err != nil {
+		return fmt.Errorf("failed to write csv row: %w", err)
+	}
+	return nil
+}
+
+func (t *csvTableWriter) Close() error {
+	t.w.Flush()
+	if err := t.w.Error(); err != nil {
+		return fmt.Errorf("failed to flush csv: %w", err)
+	}
+	return nil
+}
+
+type pdfTableWriter struct {
+	w       *pdf.Writer
+	columns []column
+}
+
+func newPDFTableWriter(w http.ResponseWriter, title string, columns []column) *pdfTableWriter {
+	t := &pdfTableWriter{columns: columns}
+	names := make([]string, 0, len(columns))
+	for _, c := range columns {
+		names = append(names, c.name)
+	}
+	header := t.format(names)
+	t.w = pdf.NewWriter(w,
+		fmt.Sprintf("Gophermart %s, generated %s", title, time.Now().UTC().Format(exportDate)),
+		"",
+		header,
+		strings.Repeat("-", len(header)),
+	)
+	return t
+}
+
+// format pads every cell to its column width, cutting cells that don't fit.
+func (t *pdfTableWriter) format(cells []string) string {
+	var b strings.Builder
+	for i, cell := range cells {
+		width := t.columns[i].width
+		runes := []rune(cell)
+		if len(runes) > width {
+			runes = runes[:width]
+		}
+		b.WriteString(string(runes))
+		if i < len(cells)-1 {
+			b.WriteString(strings.Repeat(" ", width-len(runes)+1))
+		}
+	}
+	return b.String()
+}
+
+func (t *pdfTableWriter) WriteRow(cells ...string) error {
+	if err := t.w.WriteLine(t.format(cells)); err != nil {
+		return fmt.Errorf("failed to write pdf row: %w", err)
+	}
+	return nil
+}
+
+func (t *pdfTableWriter) Close() error {
+	if err := t.w.Close(); err != nil {
+		return fmt.Errorf("failed to finish pdf: %w", err)
+	}
+	return nil
+}
+
+// finishExport closes the table. When the export failed before anything was sent it renders a problem,
+// otherwise it aborts the connection so the client can't mistake a truncated file for a complete one.
+func finishExport(w *exportResponseWriter, req *http.Request, table tableWriter, err error) {
+	if err == nil {
+		err = table.Close()
+	}
+	if err == nil {
+		err = w.flush()
+	}
+	if err == nil {
+		return
+	}
+	if !w.sent {
+		w.Header().Del("Content-Disposition")
+		renderError(w.ResponseWriter, req, fmt.Errorf("export failed: %w", err))
+		return
+	}
+	logger.FromContext(req.Context()).Error("export failed", zap.String("uri", req.RequestURI), zap.Error(err))
+	panic(http.ErrAbortHandler)
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(exportDate)
+}
+
+var (
+	orderColumns = []column{
+		{name: "number", width: 24},
+		{name: "status", width: 11},
+		{name: "accrual", width: 14},
+		{name: "uploaded_at", width: 20},
+	}
+	withdrawalColumns = []column{
+		{name: "order", width: 24},
+		{name: "sum", width: 14},
+		{name: "processed_at", width: 20},
+	}
+	statementColumns = []column{
+		{name: "date", width: 20},
+		{name: "type", width: 10},
+		{name: "order", width: 16},
+		{name: "description", width: 16},
+		{name: "amount", width: 12},
+		{name: "balance", width: 12},
+	}
+)
+
+func (h *Handler) exportOrders(w http.ResponseWriter, req *http.Request, query *domain.OrderQuery, format string) {
+	ew := &exportResponseWriter{ResponseWriter: w}
+	table := newTableWriter(ew, format, "orders", orderColumns)
+	err := h.service.ExportOrders(req.Context(), query, func(order *domain.OrderRecord) error {
+		accrual := ""
+		if order.Accrual != nil {
+			accrual = order.Accrual.String()
+		}
+		return table.WriteRow(order.Number, order.Status, accrual, formatDate(order.UploadedAt))
+	})
+	finishExport(ew, req, table, err)
+}
+
+func (h *Handler) exportWithdrawals(
+	w http.ResponseWriter,
+	req *http.Request,
+	query *domain.WithdrawalQuery,
+	format string,
+) {
+	ew := &exportResponseWriter{ResponseWriter: w}
+	table := newTableWriter(ew, format, "withdrawals", withdrawalColumns)
+	err := h.service.ExportWithdrawals(req.Context(), query, func(withdrawal *domain.WithdrawalRecord) error {
+		return table.WriteRow(withdrawal.Order, withdrawal.Sum.String(), formatDate(withdrawal.ProcessedAt))
+	})
+	finishExport(ew, req, table, err)
+}
+
+// exportStatement frames the lines with the opening and the closing balance rows.
+func (h *Handler) exportStatement(
+	w http.ResponseWriter,
+	req *http.Request,
+	query *domain.StatementQuery,
+	format string,
+) {
+	ew := &exportResponseWriter{ResponseWriter: w}
+	table := newTableWriter(ew, format, "statement", statementColumns)
+	var closing domain.Money
+	err := h.service.ExportStatement(req.Context(), query, func(entry *domain.LedgerEntry) error {
+		closing = entry.Balance
+		amount := entry.Amount.String()
+		if entry.Type == domain.StatementOpening {
+			amount = ""
+		}
+		return table.WriteRow(
+			formatDate(entry.Date),
+			entry.Type,
+			entry.Order,
+			entry.Description,
+			amount,
+			entry.Balance.String(),
+		)
+	})
+	if err == nil {
+		closingDate := ""
+		if query.To != nil {
+			closingDate = formatDate(*query.To)
+		}
+		err = table.WriteRow(closingDate, domain.StatementClosing, "", "", "", closing.String())
+	}
+	finishExport(ew, req, table, err)
+}
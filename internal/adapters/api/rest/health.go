@@ -0,0 +1,35 @@
+package rest
+
+import (
+	"encoding/json"
+	"gophermart/internal/adapters/health"
+	"gophermart/internal/logger"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// Healthz tells the orchestrator the process is alive; it never looks at dependencies.
+func (h *Handler) Healthz(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, map[string]string{"status": health.StatusUp})
+}
+
+// Readyz reports the status of every dependency and answers 503 when any is down or shutdown has begun.
+// Errors are logged rather than served, the full report is on the admin listener.
+func (h *Handler) Readyz(w http.ResponseWriter, req *http.Request) {
+	report := h.health.Ready(req.Context())
+	log := logger.FromContext(req.Context())
+	for name, check := range report.Checks {
+		if check.Error != "" {
+			log.Warn("readiness check failed", zap.String("check", name), zap.String("error", check.Error))
+		}
+	}
+	w.Header().Set(contentType, applicationJSON)
+	w.Header().Set("Cache-Control", "no-store")
+	if report.Status != health.StatusUp {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(report.Summary()); err != nil {
+		log.Error("error encoding readiness report", zap.Error(err))
+	}
+}
@@ -1,9 +1,13 @@
 package rest
 
 import (
-	"errors"
+	"encoding/json"
+	"gophermart/internal/core/domain"
+	"gophermart/internal/errs"
+	"gophermart/internal/logger"
 	"net/http"
-	"strconv"
+
+	"go.uber.org/zap"
 )
 
 const (
@@ -11,17 +15,30 @@ const (
 	applicationJSON = "application/json"
 	authorization   = "Authorization"
 	userIDKey       = "userID"
+	apiKeyHeader    = "X-API-Key"
+	otpCodeHeader   = "X-OTP-Code"
 )
 
-func getUserID(req *http.Request) (int, error) {
-	user := req.Header.Get(userIDKey)
-	if user == "" {
-		return 0, errors.New("userID not found")
+func getPrincipal(req *http.Request) (*domain.Principal, error) {
+	principal, ok := domain.PrincipalFromContext(req.Context())
+	if !ok {
+		return nil, errs.ErrUnauthorized
 	}
-	userID, err := strconv.Atoi(user)
+	return principal, nil
+}
+
+func getUserID(req *http.Request) (int, error) {
+	principal, err := getPrincipal(req)
 	if err != nil {
-		return 0, errors.New("userID has invalid type")
+		return 0, err
 	}
+	return principal.UserID, nil
+}
 
-	return userID, nil
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set(contentType, applicationJSON)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Log.Error("error encoding response", zap.Error(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
 }
@@ -0,0 +1,198 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"gophermart/internal/core/domain"
+	"gophermart/internal/errs"
+	"gophermart/internal/logger"
+	"io"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	idempotencyKeyHeader    = "Idempotency-Key"
+	idempotentReplayed      = "Idempotent-Replayed"
+	maxIdempotencyKeyLength = 255
+)
+
+// replayedHeaders describe the stored response itself; everything else belongs to the retry.
+var replayedHeaders = []string{contentType, "Location", "Link", "ETag"}
+
+type IdempotencyStore interface {
+	ReserveIdempotencyKey(
+		ctx context.Context,
+		userID int,
+		key, fingerprint string,
+		ttl time.Duration,
+	) (*domain.IdempotencyRecord, bool, error)
+	SaveIdempotentResponse(ctx context.Context, userID int, key string, response *domain.IdempotentResponse) error
+	DeleteIdempotencyKey(ctx context.Context, userID int, key string) error
+}
+
+// idempotencyMiddleware makes requests carrying an Idempotency-Key safe to retry. The first response per user
+// and key is stored and replayed byte-for-byte, while the same key with a different request is rejected.
+// Server errors and responses the client resolves by retrying are not stored, so the key can be used again.
+func (h *Handler) idempotencyMiddleware(next http.Handler) http.Handler {
+	ttl := time.Duration(h.config.IdempotencyTTL) * time.Second
+	idempotencyFn := func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !validIdempotencyKey(key) {
+			renderError(w, r, errs.NewFieldError(idempotencyKeyHeader, "must be 1 to 255 printable ASCII characters"))
+			return
+		}
+		userID, err := getUserID(r)
+		if err != nil {
+			renderError(w, r, err)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			renderError(w, r, fmt.Errorf("%w: %w", errs.ErrMalformedBody, err))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		fingerprint := requestFingerprint(r, body)
+		record, reserved, err := h.idempotency.ReserveIdempotencyKey(r.Context(), userID, key, fingerprint, ttl)
+		if err != nil {
+			renderError(w, r, err)
+			return
+		}
+		if !reserved {
+			replayResponse(w, r, record, fingerprint)
+			return
+		}
+		rw := &recordingResponseWriter{ResponseWriter: w}
+		defer h.storeResponse(r, userID, key, rw)
+		next.ServeHTTP(rw, r)
+	}
+	return http.HandlerFunc(idempotencyFn)
+}
+
+// storeResponse keeps the recorded response for replays, or releases the key after a panic or a status
+// releaseStatus lists.
+// It runs without the request's cancellation so that a client hanging up doesn't leave the key stuck.
+func (h *Handler) storeResponse(r *http.Request, userID int, key string, rw *recordingResponseWriter) {
+	ctx := context.WithoutCancel(r.Context())
+	p := recover()
+	if p != nil || releaseStatus(rw.statusOrOK()) {
+		if err := h.idempotency.DeleteIdempotencyKey(ctx, userID, key); err != nil {
+			logger.FromContext(ctx).Error("failed to release idempotency key", zap.Error(err))
+		}
+		if p != nil {
+			panic(p)
+		}
+		return
+	}
+	response := &domain.IdempotentResponse{
+		Status: rw.statusOrOK(),
+		Header: make(map[string][]string),
+		Body:   rw.body.Bytes(),
+	}
+	for _, name := range replayedHeaders {
+		if values := rw.Header().Values(name); len(values) > 0 {
+			response.Header[name] = values
+		}
+	}
+	if err := h.idempotency.SaveIdempotentResponse(ctx, userID, key, response); err != nil {
+		logger.FromContext(ctx).Error("failed to save idempotent response", zap.Error(err))
+	}
+}
+
+// releaseStatus reports whether a retry with the same key must run again: after a server error, a rate limit,
+// or missing credentials and second factors, which the client supplies in headers outside the fingerprint.
+func releaseStatus(status int) bool {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests:
+		return true
+	default:
+		return status >= http.StatusInternalServerError
+	}
+}
+
+func replayResponse(w http.ResponseWriter, r *http.Request, record *domain.IdempotencyRecord, fingerprint string) {
+	if record.Fingerprint != fingerprint {
+		renderError(w, r, errs.ErrIdempotencyKeyReused)
+		return
+	}
+	if record.Response == nil {
+		renderError(w, r, errs.ErrIdempotencyKeyInUse)
+		return
+	}
+	for name, values := range record.Response.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(idempotentReplayed, "true")
+	w.WriteHeader(record.Response.Status)
+	if _, err := w.Write(record.Response.Body); err != nil {
+		logger.FromContext(r.Context()).Error("error writing replayed response", zap.Error(err))
+	}
+}
+
+// requestFingerprint identifies what a key was used for by method, path and body.
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func validIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < ' ' || key[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// recordingResponseWriter passes the response through while keeping a copy for later replays.
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rw *recordingResponseWriter) WriteHeader(statusCode int) {
+	if rw.status == 0 {
+		rw.status = statusCode
+	}
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rw *recordingResponseWriter) Write(p []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	rw.body.Write(p)
+	n, err := rw.ResponseWriter.Write(p)
+	if err != nil {
+		return n, fmt.Errorf("failed to write response %w", err)
+	}
+	return n, nil
+}
+
+func (rw *recordingResponseWriter) statusOrOK() int {
+	if rw.status == 0 {
+		return http.StatusOK
+	}
+	return rw.status
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rw *recordingResponseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
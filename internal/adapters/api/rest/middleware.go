@@ -1,13 +1,24 @@
 package rest
 
 import (
+	"context"
 	"fmt"
+	"gophermart/internal/core/domain"
+	"gophermart/internal/errs"
 	"gophermart/internal/logger"
+	"gophermart/internal/metrics"
+	"gophermart/internal/tracing"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
 	"go.uber.org/zap"
 )
 
@@ -35,6 +46,11 @@ func (r *loggingResponseWriter) WriteHeader(statusCode int) {
 	r.responseData.status = statusCode
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to flush streams.
+func (r *loggingResponseWriter) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
 func (h *Handler) loggingRequestMiddleware(next http.Handler) http.Handler {
 	logFn := func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
@@ -51,7 +67,8 @@ func (h *Handler) loggingRequestMiddleware(next http.Handler) http.Handler {
 		if respData.status == 0 {
 			respData.status = 200
 		}
-		logger.Log.Info("got incoming http request",
+		observeRequest(r, respData.status, duration)
+		logger.FromContext(r.Context()).Info("got incoming http request",
 			zap.String("method", r.Method),
 			zap.String("uri", r.RequestURI),
 			zap.Int("status", respData.status),
@@ -62,21 +79,129 @@ func (h *Handler) loggingRequestMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(logFn)
 }
 
+// observeRequest labels by the matched chi pattern rather than the raw path to keep cardinality bounded.
+func observeRequest(r *http.Request, status int, duration time.Duration) {
+	route := "unmatched"
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+		route = rctx.RoutePattern()
+	}
+	metrics.HTTPRequests.WithLabelValues(r.Method, route, strconv.Itoa(status)).Inc()
+	metrics.HTTPDuration.WithLabelValues(r.Method, route).Observe(duration.Seconds())
+}
+
 func (h *Handler) authorizeRequestMiddleware(next http.Handler) http.Handler {
 	authFn := func(w http.ResponseWriter, r *http.Request) {
 		rawToken := r.Header.Get(authorization)
 		accessToken := strings.TrimPrefix(rawToken, "Bearer ")
-		if accessToken == "" {
-			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		apiKey := r.Header.Get(apiKeyHeader)
+		if apiKey == "" && strings.HasPrefix(accessToken, domain.APIKeyPrefix) {
+			apiKey = accessToken
+		}
+		if accessToken == "" && apiKey == "" {
+			renderError(w, r, errs.ErrUnauthorized)
 			return
 		}
-		userID, err := h.service.GetUserID(accessToken)
+		var (
+			principal *domain.Principal
+			err       error
+		)
+		if apiKey != "" {
+			principal, err = h.service.ParseAPIKey(r.Context(), apiKey)
+		} else {
+			principal, err = h.service.ParseToken(accessToken)
+		}
 		if err != nil {
-			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			renderError(w, r, fmt.Errorf("%w: %w", errs.ErrUnauthorized, err))
 			return
 		}
-		r.Header.Set(userIDKey, strconv.Itoa(userID))
-		next.ServeHTTP(w, r)
+		if err = h.certs.CheckPartnerCert(principal, r.TLS); err != nil {
+			renderError(w, r, err)
+			return
+		}
+		ctx := domain.WithPrincipal(r.Context(), principal)
+		ctx = logger.With(ctx, zap.Int("user_id", principal.UserID))
+		next.ServeHTTP(w, r.WithContext(ctx))
 	}
 	return http.HandlerFunc(authFn)
 }
+
+func (h *Handler) requireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		scopeFn := func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := domain.PrincipalFromContext(r.Context())
+			if !ok {
+				renderError(w, r, errs.ErrUnauthorized)
+				return
+			}
+			if !principal.HasScope(scope) {
+				renderError(w, r, fmt.Errorf("%w: missing scope %s", errs.ErrForbidden, scope))
+				return
+			}
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(scopeFn)
+	}
+}
+
+// stripUserIDMiddleware drops client-supplied identity headers so nothing downstream can trust them.
+func (h *Handler) stripUserIDMiddleware(next http.Handler) http.Handler {
+	stripFn := func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Del(userIDKey)
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(stripFn)
+}
+
+// timeoutMiddleware bounds regular requests by timeout. File exports on exportRoutes stream for up to
+// exportTimeout instead, which also caps how long a slow reader holds the export's database transaction.
+func (h *Handler) timeoutMiddleware(timeout, exportTimeout time.Duration) func(http.Handler) http.Handler {
+	withTimeout := middleware.Timeout(timeout)
+	exports := chi.NewRouter()
+	for _, pattern := range exportRoutes {
+		exports.Get(pattern, http.NotFound)
+	}
+	return func(next http.Handler) http.Handler {
+		bounded := withTimeout(next)
+		timeoutFn := func(w http.ResponseWriter, r *http.Request) {
+			if !isExport(r) || !exports.Match(chi.NewRouteContext(), r.Method, r.URL.Path) {
+				bounded.ServeHTTP(w, r)
+				return
+			}
+			ctx, cancel := context.WithTimeout(r.Context(), exportTimeout)
+			defer cancel()
+			// The deadline unblocks writes to a client that stopped reading, so the export can give up.
+			if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(exportTimeout)); err != nil {
+				logger.FromContext(ctx).Debug("failed to set export write deadline", zap.Error(err))
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+		return http.HandlerFunc(timeoutFn)
+	}
+}
+
+// tracingMiddleware continues the caller's trace from traceparent and names the span after the chi route.
+func (h *Handler) tracingMiddleware(next http.Handler) http.Handler {
+	traceFn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.StartServer(ctx, r.Method,
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.path", r.URL.Path),
+		)
+		defer span.End()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			span.SetName(r.Method + " " + rctx.RoutePattern())
+			span.SetAttributes(attribute.String("http.route", rctx.RoutePattern()))
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+	return http.HandlerFunc(traceFn)
+}
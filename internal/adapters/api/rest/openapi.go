@@ -0,0 +1,200 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gophermart/internal/errs"
+	"gophermart/internal/logger"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"go.uber.org/zap"
+)
+
+//go:embed openapi/openapi.yaml
+var openAPISpec []byte
+
+type openAPI struct {
+	router   routers.Router
+	document []byte
+}
+
+func newOpenAPI() (*openAPI, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(openAPISpec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load openapi spec: %w", err)
+	}
+	if err = doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid openapi spec: %w", err)
+	}
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build openapi router: %w", err)
+	}
+	document, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal openapi spec: %w", err)
+	}
+	return &openAPI{router: router, document: document}, nil
+}
+
+func (o *openAPI) ServeSpec(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set(contentType, applicationJSON)
+	if _, err := w.Write(o.document); err != nil {
+		logger.Log.Error("error writing openapi spec", zap.Error(err))
+	}
+}
+
+// validationMiddleware checks requests against the spec and, with validateResponses,
+// also the responses. Routes missing from the spec are passed through untouched.
+func (o *openAPI) validationMiddleware(validateResponses bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		validateFn := func(w http.ResponseWriter, r *http.Request) {
+			route, pathParams, err := o.router.FindRoute(r)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			input := &openapi3filter.RequestValidationInput{
+				Request:    r,
+				PathParams: pathParams,
+				Route:      route,
+				Options: &openapi3filter.Options{
+					AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+					MultiError:         true,
+				},
+			}
+			if err = openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+				if !errors.Is(err, errs.ErrPayloadTooLarge) {
+					err = toValidationError(err)
+				}
+				renderError(w, r, err)
+				return
+			}
+			if !validateResponses || isStream(route) || isExport(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			bw := &bufferedResponseWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(bw, r)
+			err = openapi3filter.ValidateResponse(r.Context(), &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: input,
+				Status:                 bw.status,
+				Header:                 bw.Header(),
+				Body:                   io.NopCloser(bytes.NewReader(bw.body.Bytes())),
+				Options:                &openapi3filter.Options{IncludeResponseStatus: true, MultiError: true},
+			})
+			if err != nil {
+				logger.FromContext(r.Context()).Error("response does not match openapi spec",
+					zap.String("method", r.Method),
+					zap.String("uri", r.RequestURI),
+					zap.Int("status", bw.status),
+					zap.Error(err),
+				)
+				renderError(w, r, fmt.Errorf("response contract violation: %w", err))
+				return
+			}
+			bw.flush()
+		}
+		return http.HandlerFunc(validateFn)
+	}
+}
+
+// isStream reports whether the route responds with an event stream, which can't be buffered for validation.
+func isStream(route *routers.Route) bool {
+	response := route.Operation.Responses.Status(http.StatusOK)
+	return response != nil && response.Value != nil && response.Value.Content.Get(textEventStream) != nil
+}
+
+// isExport reports whether the request asks for a file export, which is streamed rather than buffered.
+func isExport(r *http.Request) bool {
+	format, err := exportFormat(r)
+	return err == nil && format != ""
+}
+
+func toValidationError(err error) error {
+	var (
+		validationErr errs.ValidationError
+		multiErr      openapi3.MultiError
+	)
+	if !errors.As(err, &multiErr) {
+		multiErr = openapi3.MultiError{err}
+	}
+	for _, e := range multiErr {
+		var requestErr *openapi3filter.RequestError
+		if !errors.As(e, &requestErr) {
+			validationErr.Add("request", e.Error())
+			continue
+		}
+		if requestErr.Parameter != nil {
+			var schemaErr *openapi3.SchemaError
+			if errors.As(requestErr.Err, &schemaErr) {
+				validationErr.Add(requestErr.Parameter.Name, schemaErr.Reason)
+			} else {
+				validationErr.Add(requestErr.Parameter.Name, requestErr.Error())
+			}
+			continue
+		}
+		addSchemaErrors(&validationErr, requestErr)
+	}
+	return validationErr.Err()
+}
+
+// addSchemaErrors reports body schema violations by their JSON path instead of the raw schema dump.
+func addSchemaErrors(validationErr *errs.ValidationError, requestErr *openapi3filter.RequestError) {
+	if requestErr.Err == nil {
+		validationErr.Add("body", requestErr.Error())
+		return
+	}
+	var schemaErrs openapi3.MultiError
+	if !errors.As(requestErr.Err, &schemaErrs) {
+		schemaErrs = openapi3.MultiError{requestErr.Err}
+	}
+	for _, e := range schemaErrs {
+		var schemaErr *openapi3.SchemaError
+		if !errors.As(e, &schemaErr) {
+			validationErr.Add("body", e.Error())
+			continue
+		}
+		field := strings.Join(schemaErr.JSONPointer(), ".")
+		if field == "" {
+			field = "body"
+		}
+		validationErr.Add(field, schemaErr.Reason)
+	}
+}
+
+type bufferedResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponseWriter) Write(p []byte) (int, error) {
+	n, err := b.body.Write(p)
+	if err != nil {
+		return n, fmt.Errorf("failed to buffer response %w", err)
+	}
+	return n, nil
+}
+
+func (b *bufferedResponseWriter) WriteHeader(statusCode int) {
+	b.status = statusCode
+}
+
+func (b *bufferedResponseWriter) flush() {
+	b.ResponseWriter.WriteHeader(b.status)
+	if _, err := b.ResponseWriter.Write(b.body.Bytes()); err != nil {
+		logger.Log.Error("error writing buffered response", zap.Error(err))
+	}
+}
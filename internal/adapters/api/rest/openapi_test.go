@@ -0,0 +1,84 @@
+package rest
+
+import (
+	"gophermart/internal/config"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+)
+
+// undocumentedRoutes serve operators rather than API clients and are left out of the spec on purpose.
+var undocumentedRoutes = map[string]bool{
+	"GET /healthz": true,
+	"GET /readyz":  true,
+}
+
+// TestRoutesMatchSpec keeps the router and openapi.yaml in step: every route is documented
+// and every documented operation is routed.
+func TestRoutesMatchSpec(t *testing.T) {
+	api, err := NewAPI(&config.Config{}, nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("NewAPI: %v", err)
+	}
+	router, ok := api.srv.Handler.(chi.Routes)
+	if !ok {
+		t.Fatalf("server handler is %T, not a chi router", api.srv.Handler)
+	}
+	routes := routerOperations(t, router)
+	documented := specOperations(t)
+	for _, op := range sortedKeys(routes) {
+		if !documented[op] && !undocumentedRoutes[op] {
+			t.Errorf("route %s is missing from openapi.yaml", op)
+		}
+	}
+	for _, op := range sortedKeys(documented) {
+		if !routes[op] {
+			t.Errorf("openapi.yaml documents %s, but no route serves it", op)
+		}
+	}
+}
+
+func routerOperations(t *testing.T, router chi.Routes) map[string]bool {
+	t.Helper()
+	ops := make(map[string]bool)
+	err := chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		// chi names routes of subrouters with a trailing slash, e.g. /api/user/balance/.
+		if len(route) > 1 {
+			route = strings.TrimSuffix(route, "/")
+		}
+		ops[method+" "+route] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("chi.Walk: %v", err)
+	}
+	return ops
+}
+
+func specOperations(t *testing.T) map[string]bool {
+	t.Helper()
+	doc, err := openapi3.NewLoader().LoadFromData(openAPISpec)
+	if err != nil {
+		t.Fatalf("failed to load openapi spec: %v", err)
+	}
+	ops := make(map[string]bool)
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			ops[method+" "+path] = true
+		}
+	}
+	return ops
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
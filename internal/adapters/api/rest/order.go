@@ -1,68 +1,83 @@
 package rest
 
 import (
-	"encoding/json"
 	"errors"
 	"gophermart/internal/core/domain"
 	"gophermart/internal/errs"
-	"gophermart/internal/logger"
-	"io"
 	"net/http"
 
-	"go.uber.org/zap"
+	"github.com/go-chi/chi/v5"
 )
 
 func (h *Handler) CreateOrder(w http.ResponseWriter, req *http.Request) {
-	orderNumber, err := io.ReadAll(req.Body)
-	if err != nil || len(orderNumber) == 0 {
-		logger.Log.Info("cannot read order number", zap.Error(err))
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+	orderNumber, err := readText(req)
+	if err != nil {
+		renderError(w, req, err)
 		return
 	}
-	userID, err := getUserID(req)
-	if err != nil {
-		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	if orderNumber == "" {
+		renderError(w, req, errs.NewFieldError("body", "order number is required"))
 		return
 	}
-	err = h.service.CreateOrder(req.Context(), userID, &domain.OrderIn{Number: string(orderNumber)})
-	if err == nil {
-		w.WriteHeader(http.StatusAccepted)
+	userID, err := getUserID(req)
+	if err != nil {
+		renderError(w, req, err)
 		return
 	}
-	statusCode := http.StatusInternalServerError
+	err = h.service.CreateOrder(req.Context(), userID, &domain.OrderIn{Number: orderNumber})
 	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusAccepted)
 	case errors.Is(err, errs.ErrOrderAlreadyAdded):
-		statusCode = http.StatusOK
-	case errors.Is(err, errs.ErrUnreachableOrder):
-		statusCode = http.StatusConflict
-	case errors.Is(err, errs.ErrInvalidOrderNumber):
-		statusCode = http.StatusUnprocessableEntity
-	}
-	if statusCode == http.StatusInternalServerError {
-		logger.Log.Error("unexpected error occurred during creating order", zap.Error(err))
+		w.WriteHeader(http.StatusOK)
+	default:
+		renderError(w, req, err)
 	}
-	http.Error(w, http.StatusText(statusCode), statusCode)
 }
 
 func (h *Handler) GetAllOrders(w http.ResponseWriter, req *http.Request) {
 	userID, err := getUserID(req)
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		renderError(w, req, err)
+		return
+	}
+	query, err := parseOrderQuery(req, userID)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	format, err := exportFormat(req)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	if format != "" {
+		h.exportOrders(w, req, query, format)
 		return
 	}
-	orders, err := h.service.GetAllOrders(req.Context(), userID)
+	page, err := h.service.GetAllOrders(req.Context(), query)
 	if err != nil {
-		logger.Log.Error("error occurred during getting all orders", zap.Error(err))
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		renderError(w, req, err)
 		return
 	}
-	if len(orders) == 0 {
+	if len(page.Orders) == 0 {
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
-	w.Header().Set(contentType, applicationJSON)
-	if err = json.NewEncoder(w).Encode(orders); err != nil {
-		logger.Log.Error("error encoding orders", zap.Error(err))
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	setNextLink(w, req, page.NextCursor)
+	writeJSON(w, page.Orders)
+}
+
+func (h *Handler) GetOrder(w http.ResponseWriter, req *http.Request) {
+	userID, err := getUserID(req)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	order, err := h.service.GetOrder(req.Context(), userID, chi.URLParam(req, "number"))
+	if err != nil {
+		renderError(w, req, err)
+		return
 	}
+	writeJSONWithETag(w, req, order)
 }
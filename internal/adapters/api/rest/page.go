@@ -0,0 +1,93 @@
+package rest
+
+import (
+	"fmt"
+	"gophermart/internal/adapters/api/validation"
+	"gophermart/internal/core/domain"
+	"gophermart/internal/errs"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	limitParam  = "limit"
+	cursorParam = "cursor"
+)
+
+// parsePageQuery reads limit, cursor, sort, from and to from the query string.
+// Syntax errors are collected into v so they are reported together.
+func parsePageQuery(v *errs.ValidationError, req *http.Request, userID int) domain.PageQuery {
+	values := req.URL.Query()
+	query := domain.PageQuery{
+		UserID: userID,
+		Cursor: values.Get(cursorParam),
+		Sort:   strings.ToLower(values.Get("sort")),
+	}
+	if raw := values.Get(limitParam); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit == 0 {
+			v.Add(limitParam, "must be a positive integer")
+		}
+		query.Limit = limit
+	}
+	query.From = parseTimeParam(v, values.Get("from"), "from")
+	query.To = parseTimeParam(v, values.Get("to"), "to")
+	return query
+}
+
+func parseTimeParam(v *errs.ValidationError, raw, field string) *time.Time {
+	if raw == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		v.Add(field, "must be an RFC 3339 timestamp")
+		return nil
+	}
+	return &t
+}
+
+func parseOrderQuery(req *http.Request, userID int) (*domain.OrderQuery, error) {
+	var v errs.ValidationError
+	query := &domain.OrderQuery{PageQuery: parsePageQuery(&v, req, userID)}
+	for _, raw := range req.URL.Query()["status"] {
+		for _, status := range strings.Split(raw, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				query.Statuses = append(query.Statuses, strings.ToUpper(status))
+			}
+		}
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+	if err := validation.ValidateOrderQuery(query); err != nil {
+		return nil, fmt.Errorf("invalid order query: %w", err)
+	}
+	return query, nil
+}
+
+func parseWithdrawalQuery(req *http.Request, userID int) (*domain.WithdrawalQuery, error) {
+	var v errs.ValidationError
+	query := &domain.WithdrawalQuery{PageQuery: parsePageQuery(&v, req, userID)}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+	if err := validation.ValidatePageQuery(&query.PageQuery); err != nil {
+		return nil, fmt.Errorf("invalid withdrawal query: %w", err)
+	}
+	return query, nil
+}
+
+// setNextLink advertises the next page as an RFC 8288 Link header that keeps the current filters.
+func setNextLink(w http.ResponseWriter, req *http.Request, nextCursor string) {
+	if nextCursor == "" {
+		return
+	}
+	values := req.URL.Query()
+	values.Set(cursorParam, nextCursor)
+	next := *req.URL
+	next.RawQuery = values.Encode()
+	w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"next\"", next.RequestURI()))
+}
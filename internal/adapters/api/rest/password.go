@@ -0,0 +1,41 @@
+package rest
+
+import (
+	"gophermart/internal/adapters/api/validation"
+	"gophermart/internal/core/domain"
+	"net/http"
+)
+
+func (h *Handler) ForgotPassword(w http.ResponseWriter, req *http.Request) {
+	var forgot domain.PasswordForgotIn
+	if err := decodeJSON(req, &forgot); err != nil {
+		renderError(w, req, err)
+		return
+	}
+	if err := validation.ValidatePasswordForgotIn(&forgot); err != nil {
+		renderError(w, req, err)
+		return
+	}
+	if err := h.service.ForgotPassword(req.Context(), &forgot); err != nil {
+		renderError(w, req, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *Handler) ResetPassword(w http.ResponseWriter, req *http.Request) {
+	var reset domain.PasswordResetIn
+	if err := decodeJSON(req, &reset); err != nil {
+		renderError(w, req, err)
+		return
+	}
+	if err := validation.ValidatePasswordResetIn(&reset); err != nil {
+		renderError(w, req, err)
+		return
+	}
+	if err := h.service.ResetPassword(req.Context(), &reset); err != nil {
+		renderError(w, req, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,57 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"gophermart/internal/adapters/api/apierr"
+	"gophermart/internal/errs"
+	"gophermart/internal/logger"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+const (
+	applicationProblemJSON = "application/problem+json"
+	problemTypePrefix      = "https://gophermart.local/problems/"
+)
+
+type problem struct {
+	Type     string            `json:"type"`
+	Title    string            `json:"title"`
+	Status   int               `json:"status"`
+	Code     string            `json:"code"`
+	Detail   string            `json:"detail,omitempty"`
+	Instance string            `json:"instance,omitempty"`
+	Errors   []errs.FieldError `json:"errors,omitempty"`
+}
+
+// renderError writes err as an application/problem+json response.
+func renderError(w http.ResponseWriter, req *http.Request, err error) {
+	m, known := apierr.Lookup(err)
+	p := problem{
+		Status:   m.Status,
+		Code:     m.Code,
+		Instance: req.URL.Path,
+	}
+	if known {
+		p.Detail = m.Err.Error()
+	}
+	var validationErr *errs.ValidationError
+	if errors.As(err, &validationErr) {
+		p.Errors = validationErr.Fields
+	}
+	p.Type = problemTypePrefix + p.Code
+	p.Title = http.StatusText(p.Status)
+	log := logger.FromContext(req.Context())
+	if p.Status >= http.StatusInternalServerError {
+		log.Error("unexpected error occurred", zap.String("uri", req.RequestURI), zap.Error(err))
+	} else {
+		log.Info("request failed", zap.String("uri", req.RequestURI), zap.Error(err))
+	}
+	w.Header().Set(contentType, applicationProblemJSON)
+	w.WriteHeader(p.Status)
+	if encodeErr := json.NewEncoder(w).Encode(p); encodeErr != nil {
+		log.Error("error encoding problem", zap.Error(encodeErr))
+	}
+}
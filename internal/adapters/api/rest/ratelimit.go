@@ -0,0 +1,67 @@
+package rest
+
+import (
+	"gophermart/internal/adapters/ratelimit"
+	"gophermart/internal/core/domain"
+	"gophermart/internal/errs"
+	"gophermart/internal/logger"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Route groups share one counter per client, so a client exhausting one group can still use the others.
+const (
+	rateLimitAuth    = ratelimit.GroupAuth
+	rateLimitOrders  = "orders"
+	rateLimitBalance = "balance"
+	rateLimitUser    = "user"
+)
+
+// rateLimitMiddleware allows limit requests per window for every authenticated user of the group,
+// or for every client IP on anonymous routes. A non-positive limit turns it off.
+func (h *Handler) rateLimitMiddleware(group string, limit int) func(http.Handler) http.Handler {
+	window := time.Duration(h.config.RateLimitWindow) * time.Second
+	return func(next http.Handler) http.Handler {
+		if h.limiter == nil || limit <= 0 || window <= 0 {
+			return next
+		}
+		limitFn := func(w http.ResponseWriter, r *http.Request) {
+			result, err := h.limiter.Take(r.Context(), group+":"+h.clientKey(r), limit, window)
+			if err != nil {
+				// An unavailable store must not take the API down with it.
+				logger.FromContext(r.Context()).Error("failed to check rate limit", zap.String("group", group), zap.Error(err))
+				next.ServeHTTP(w, r)
+				return
+			}
+			reset := strconv.Itoa(int(math.Ceil(time.Until(result.Reset).Seconds())))
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("RateLimit-Reset", reset)
+			w.Header().Set("RateLimit-Policy", strconv.Itoa(limit)+";w="+strconv.Itoa(h.config.RateLimitWindow))
+			if !result.Allowed {
+				w.Header().Set("Retry-After", reset)
+				renderError(w, r, errs.ErrTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(limitFn)
+	}
+}
+
+// clientKey identifies the caller by user when authenticated and by the client address otherwise,
+// see ratelimit.ClientIP for where that address comes from.
+func (h *Handler) clientKey(r *http.Request) string {
+	if principal, ok := domain.PrincipalFromContext(r.Context()); ok {
+		return "user:" + strconv.Itoa(principal.UserID)
+	}
+	var forwarded string
+	if h.config.RateLimitIPHeader != "" {
+		forwarded = r.Header.Get(h.config.RateLimitIPHeader)
+	}
+	return "ip:" + ratelimit.ClientIP(r.RemoteAddr, forwarded)
+}
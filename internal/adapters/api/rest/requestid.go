@@ -0,0 +1,63 @@
+package rest
+
+import (
+	"gophermart/internal/logger"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	requestIDHeader    = "X-Request-ID"
+	maxRequestIDLength = 128
+)
+
+// requestIDMiddleware reuses the caller's X-Request-ID when it looks sane, generates one otherwise,
+// echoes it back and puts a request-scoped logger into the context.
+func (h *Handler) requestIDMiddleware(next http.Handler) http.Handler {
+	requestIDFn := func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(requestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = logger.NewCorrelationID()
+		}
+		w.Header().Set(requestIDHeader, requestID)
+		ctx := logger.With(r.Context(),
+			zap.String("request_id", requestID),
+			zap.Stringer("route", routePattern{r}),
+		)
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("http.request.id", requestID))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+	return http.HandlerFunc(requestIDFn)
+}
+
+// routePattern resolves the chi route lazily, since it is only known once routing has finished.
+type routePattern struct {
+	r *http.Request
+}
+
+func (p routePattern) String() string {
+	if rctx := chi.RouteContext(p.r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+		return rctx.RoutePattern()
+	}
+	return "unmatched"
+}
+
+// validRequestID accepts ids short enough and plain enough to be safe in logs and response headers.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -4,7 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"gophermart/internal/adapters/health"
+	"gophermart/internal/adapters/ratelimit"
+	"gophermart/internal/adapters/tlsconfig"
 	"gophermart/internal/core/domain"
+	"gophermart/internal/errs"
 	"net/http"
 	"time"
 
@@ -12,7 +16,6 @@ import (
 	"gophermart/internal/logger"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
 	"go.uber.org/zap"
 )
 
@@ -20,71 +23,247 @@ const (
 	serverTimeout = 3
 )
 
+// exportRoutes stream csv and pdf exports on request and are the only routes exempt from serverTimeout.
+var exportRoutes = []string{
+	"/api/user/orders",
+	"/api/user/withdrawals",
+	"/api/user/balance/statement",
+}
+
 type Service interface {
 	CreateUser(ctx context.Context, user *domain.UserIn) error
-	CreateToken(ctx context.Context, user *domain.UserIn) (string, error)
-	GetUserID(accessToken string) (int, error)
+	CreateToken(ctx context.Context, user *domain.UserIn) (*domain.Token, error)
+	CompleteMFALogin(ctx context.Context, mfaIn *domain.MFALoginIn) (*domain.Token, error)
+	ParseToken(accessToken string) (*domain.Principal, error)
 	CreateOrder(ctx context.Context, userID int, order *domain.OrderIn) error
-	GetAllOrders(ctx context.Context, userID int) (domain.OrderOutList, error)
+	CreateOrders(ctx context.Context, userID int, numbers []string) (domain.BulkOrderResults, error)
+	GetAllOrders(ctx context.Context, query *domain.OrderQuery) (*domain.OrderPage, error)
+	ExportOrders(ctx context.Context, query *domain.OrderQuery, fn func(order *domain.OrderRecord) error) error
+	GetOrder(ctx context.Context, userID int, number string) (*domain.OrderOut, error)
 	GetBalance(ctx context.Context, userID int) (*domain.BalanceOut, error)
 	WithdrawBonuses(ctx context.Context, userID int, withdraw *domain.WithdrawalIn) error
-	GetAllWithdrawals(ctx context.Context, userID int) (domain.WithdrawOutList, error)
+	GetAllWithdrawals(ctx context.Context, query *domain.WithdrawalQuery) (*domain.WithdrawalPage, error)
+	GetStatement(ctx context.Context, query *domain.StatementQuery) (*domain.Statement, error)
+	ExportStatement(ctx context.Context, query *domain.StatementQuery, fn func(entry *domain.LedgerEntry) error) error
+	ExportWithdrawals(
+		ctx context.Context,
+		query *domain.WithdrawalQuery,
+		fn func(withdrawal *domain.WithdrawalRecord) error,
+	) error
+	FindUserByLogin(ctx context.Context, adminID int, login string) (*domain.UserOut, error)
+	GetUser(ctx context.Context, adminID, userID int) (*domain.UserOut, error)
+	GetUserOrders(ctx context.Context, adminID int, query *domain.OrderQuery) (*domain.OrderPage, error)
+	GetUserWithdrawals(
+		ctx context.Context,
+		adminID int,
+		query *domain.WithdrawalQuery,
+	) (*domain.WithdrawalPage, error)
+	GetUserBalance(ctx context.Context, adminID, userID int) (*domain.BalanceOut, error)
+	RequeueOrder(ctx context.Context, adminID int, number string) error
+	AdjustBalance(ctx context.Context, adminID, userID int, adjustment *domain.BalanceAdjustmentIn) error
+	CreateAPIKey(ctx context.Context, userID int, keyIn *domain.APIKeyIn) (*domain.APIKeyCreated, error)
+	GetAllAPIKeys(ctx context.Context, userID int) (domain.APIKeyList, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID int) error
+	ParseAPIKey(ctx context.Context, rawKey string) (*domain.Principal, error)
+	EnrollTOTP(ctx context.Context, userID int) (*domain.TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, userID int, code string) (*domain.RecoveryCodes, error)
+	DisableTOTP(ctx context.Context, userID int, code string) error
+	ForgotPassword(ctx context.Context, forgot *domain.PasswordForgotIn) error
+	ResetPassword(ctx context.Context, reset *domain.PasswordResetIn) error
+	SubscribeOrderEvents(ctx context.Context, userID int, lastEventID int64) (<-chan domain.OrderEvent, error)
 }
 
 type Handler struct {
-	service Service
-	config  *config.Config
+	service     Service
+	config      *config.Config
+	limiter     ratelimit.Store
+	health      *health.Health
+	idempotency IdempotencyStore
+	certs       *tlsconfig.Reloader
 }
 
 type API struct {
-	srv *http.Server
+	srv             *http.Server
+	shutdownTimeout time.Duration
 }
 
+// Run serves until ctx is done, then waits for in-flight requests to finish within the shutdown timeout.
 func (a *API) Run(ctx context.Context) error {
+	shutdownDone := make(chan struct{})
 	go func() {
+		defer close(shutdownDone)
 		<-ctx.Done()
-		if err := a.srv.Shutdown(ctx); err != nil {
+		// ctx is already done here, in-flight requests get a fresh deadline to finish.
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), a.shutdownTimeout)
+		defer cancel()
+		if err := a.srv.Shutdown(shutdownCtx); err != nil {
 			logger.Log.Info("gophermart shutdown: ", zap.Error(err))
 		}
 	}()
-	if err := a.srv.ListenAndServe(); err != nil {
+	if err := a.listenAndServe(); err != nil {
 		logger.Log.Error("error occurred during running gophermart: ", zap.Error(err))
 		if !errors.Is(err, http.ErrServerClosed) {
 			return fmt.Errorf("failed run gophermart: %w", err)
 		}
 	}
+	<-shutdownDone
 	return nil
 }
 
-func NewAPI(cfg *config.Config, srv Service) *API {
+// listenAndServe terminates TLS with hot-reloaded certificates when configured, serving HTTP/2 as well.
+func (a *API) listenAndServe() error {
+	if a.srv.TLSConfig == nil {
+		return a.srv.ListenAndServe() //nolint:wrapcheck // wrapped by Run
+	}
+	return a.srv.ListenAndServeTLS("", "") //nolint:wrapcheck // wrapped by Run
+}
+
+func NewAPI(
+	cfg *config.Config,
+	srv Service,
+	limiter ratelimit.Store,
+	probe *health.Health,
+	idempotency IdempotencyStore,
+	certs *tlsconfig.Reloader,
+) (*API, error) {
 	h := &Handler{
-		config:  cfg,
-		service: srv,
+		config:      cfg,
+		service:     srv,
+		limiter:     limiter,
+		health:      probe,
+		idempotency: idempotency,
+		certs:       certs,
+	}
+	spec, err := newOpenAPI()
+	if err != nil {
+		return nil, err
+	}
+	cors, err := newCORSPolicy(cfg)
+	if err != nil {
+		return nil, err
 	}
 	r := chi.NewRouter()
 
+	r.Use(h.tracingMiddleware)
+	r.Use(h.requestIDMiddleware)
 	r.Use(h.loggingRequestMiddleware)
-	r.Use(middleware.Timeout(serverTimeout * time.Second))
-	r.Post("/api/user/register", h.SignUp)
-	r.Post("/api/user/login", h.SignIn)
-	r.Mount("/api/user/", ordersRouter(h))
-	return &API{
+	r.Use(h.securityHeadersMiddleware)
+	if cors.enabled() {
+		r.Use(h.corsMiddleware(cors))
+	}
+	r.Use(h.stripUserIDMiddleware)
+	r.Use(h.compressMiddleware(cfg.CompressionMinSize))
+	r.Use(h.decompressMiddleware(cfg.MaxDecompressedBody))
+	r.Use(h.bodyLimitMiddleware(maxRequestBody))
+	if cfg.OpenAPIValidation {
+		r.Use(spec.validationMiddleware(cfg.IsDev()))
+	}
+	r.NotFound(func(w http.ResponseWriter, req *http.Request) {
+		renderError(w, req, errs.ErrNotFound)
+	})
+	r.Get("/healthz", h.Healthz)
+	r.Get("/readyz", h.Readyz)
+	// Streams are long-lived and stay outside of the request timeout.
+	r.With(
+		h.authorizeRequestMiddleware,
+		h.rateLimitMiddleware(rateLimitOrders, cfg.RateLimitOrders),
+		h.requireScope(domain.ScopeOrdersRead),
+	).Get("/api/user/orders/events", h.StreamOrderEvents)
+	r.Group(func(r chi.Router) {
+		r.Use(h.timeoutMiddleware(serverTimeout*time.Second, time.Duration(cfg.ExportTimeout)*time.Second))
+		r.Get("/api/openapi.json", spec.ServeSpec)
+		r.Group(func(r chi.Router) {
+			r.Use(h.rateLimitMiddleware(rateLimitAuth, cfg.RateLimitAuth))
+			r.Use(h.bodyLimitMiddleware(maxJSONBody))
+			r.Post("/api/user/register", h.SignUp)
+			r.Post("/api/user/login", h.SignIn)
+			r.Post("/api/user/login/2fa", h.SignInMFA)
+			r.Post("/api/user/password/forgot", h.ForgotPassword)
+			r.Post("/api/user/password/reset", h.ResetPassword)
+		})
+		r.Mount("/api/user/", ordersRouter(h))
+		r.Mount("/api/admin/", adminRouter(h))
+	})
+	api := &API{
 		srv: &http.Server{
 			Addr:    cfg.Address,
 			Handler: r,
 		},
+		shutdownTimeout: time.Duration(cfg.ShutdownTimeout) * time.Second,
 	}
+	if certs != nil {
+		api.srv.TLSConfig = certs.TLSConfig()
+	}
+	return api, nil
 }
 
 func ordersRouter(h *Handler) chi.Router {
 	r := chi.NewRouter()
 	r.Use(h.authorizeRequestMiddleware)
-	r.Post("/orders", h.CreateOrder)
-	r.Get("/orders", h.GetAllOrders)
-	r.Get("/withdrawals", h.GetAllWithdrawals)
-	r.Route("/balance", func(r chi.Router) {
-		r.Get("/", h.GetBalance)
-		r.Post("/withdraw", h.WithdrawBonuses)
+	r.Group(func(r chi.Router) {
+		r.Use(h.rateLimitMiddleware(rateLimitOrders, h.config.RateLimitOrders))
+		r.With(
+			h.requireScope(domain.ScopeOrdersWrite),
+			h.bodyLimitMiddleware(maxOrderBody),
+			h.idempotencyMiddleware,
+		).Post("/orders", h.CreateOrder)
+		r.With(
+			h.requireScope(domain.ScopeOrdersWrite),
+			h.bodyLimitMiddleware(maxBatchBody),
+			h.idempotencyMiddleware,
+		).Post("/orders/batch", h.CreateOrders)
+		r.With(h.requireScope(domain.ScopeOrdersRead)).Get("/orders", h.GetAllOrders)
+		r.With(h.requireScope(domain.ScopeOrdersRead)).Get("/orders/{number}", h.GetOrder)
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(h.rateLimitMiddleware(rateLimitBalance, h.config.RateLimitBalance))
+		r.With(h.requireScope(domain.ScopeBalanceRead)).Get("/withdrawals", h.GetAllWithdrawals)
+		r.Route("/balance", func(r chi.Router) {
+			r.With(h.requireScope(domain.ScopeBalanceRead)).Get("/", h.GetBalance)
+			r.With(h.requireScope(domain.ScopeBalanceRead)).Get("/statement", h.GetStatement)
+			r.With(
+				h.requireScope(domain.ScopeBalanceWrite),
+				h.bodyLimitMiddleware(maxJSONBody),
+				h.idempotencyMiddleware,
+			).Post("/withdraw", h.WithdrawBonuses)
+		})
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(h.rateLimitMiddleware(rateLimitUser, h.config.RateLimitUser))
+		r.Use(h.bodyLimitMiddleware(maxJSONBody))
+		r.Route("/api-keys", func(r chi.Router) {
+			r.Use(h.requireScope(domain.ScopeAPIKeys))
+			r.Get("/", h.GetAllAPIKeys)
+			r.Post("/", h.CreateAPIKey)
+			r.Delete("/{keyID}", h.RevokeAPIKey)
+		})
+		r.Route("/2fa", func(r chi.Router) {
+			r.Use(h.requireScope(domain.ScopeAccount))
+			r.Post("/enroll", h.EnrollTOTP)
+			r.Post("/confirm", h.ConfirmTOTP)
+			r.Post("/disable", h.DisableTOTP)
+		})
+	})
+	return r
+}
+
+func adminRouter(h *Handler) chi.Router {
+	r := chi.NewRouter()
+	r.Use(h.authorizeRequestMiddleware)
+	r.Use(h.bodyLimitMiddleware(maxJSONBody))
+	r.Group(func(r chi.Router) {
+		r.Use(h.requireScope(domain.ScopeAdminRead))
+		r.Get("/users", h.AdminFindUser)
+		r.Get("/users/{userID}", h.AdminGetUser)
+		r.Get("/users/{userID}/orders", h.AdminGetUserOrders)
+		r.Get("/users/{userID}/withdrawals", h.AdminGetUserWithdrawals)
+		r.Get("/users/{userID}/balance", h.AdminGetUserBalance)
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(h.requireScope(domain.ScopeAdminWrite))
+		r.Use(h.idempotencyMiddleware)
+		r.Post("/users/{userID}/balance/adjustments", h.AdminAdjustBalance)
+		r.Post("/orders/{number}/requeue", h.AdminRequeueOrder)
 	})
 	return r
 }
@@ -0,0 +1,64 @@
+package rest
+
+import (
+	"gophermart/internal/adapters/api/validation"
+	"gophermart/internal/core/domain"
+	"net/http"
+)
+
+func (h *Handler) EnrollTOTP(w http.ResponseWriter, req *http.Request) {
+	userID, err := getUserID(req)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	enrollment, err := h.service.EnrollTOTP(req.Context(), userID)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	writeJSON(w, enrollment)
+}
+
+func (h *Handler) ConfirmTOTP(w http.ResponseWriter, req *http.Request) {
+	userID, codeIn, ok := decodeTOTPCode(w, req)
+	if !ok {
+		return
+	}
+	codes, err := h.service.ConfirmTOTP(req.Context(), userID, codeIn.Code)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	writeJSON(w, codes)
+}
+
+func (h *Handler) DisableTOTP(w http.ResponseWriter, req *http.Request) {
+	userID, codeIn, ok := decodeTOTPCode(w, req)
+	if !ok {
+		return
+	}
+	if err := h.service.DisableTOTP(req.Context(), userID, codeIn.Code); err != nil {
+		renderError(w, req, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func decodeTOTPCode(w http.ResponseWriter, req *http.Request) (int, *domain.TOTPCodeIn, bool) {
+	var codeIn domain.TOTPCodeIn
+	userID, err := getUserID(req)
+	if err != nil {
+		renderError(w, req, err)
+		return 0, nil, false
+	}
+	if err = decodeJSON(req, &codeIn); err != nil {
+		renderError(w, req, err)
+		return 0, nil, false
+	}
+	if err = validation.ValidateTOTPCodeIn(&codeIn); err != nil {
+		renderError(w, req, err)
+		return 0, nil, false
+	}
+	return userID, &codeIn, true
+}
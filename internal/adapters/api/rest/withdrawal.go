@@ -1,90 +1,115 @@
 package rest
 
 import (
-	"encoding/json"
-	"errors"
 	"gophermart/internal/adapters/api/validation"
 	"gophermart/internal/core/domain"
 	"gophermart/internal/errs"
-	"gophermart/internal/logger"
 	"net/http"
-
-	"go.uber.org/zap"
 )
 
 func (h *Handler) GetAllWithdrawals(w http.ResponseWriter, req *http.Request) {
 	userID, err := getUserID(req)
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		renderError(w, req, err)
 		return
 	}
-	withdrawals, err := h.service.GetAllWithdrawals(req.Context(), userID)
-	if len(withdrawals) == 0 {
-		w.WriteHeader(http.StatusNoContent)
+	query, err := parseWithdrawalQuery(req, userID)
+	if err != nil {
+		renderError(w, req, err)
 		return
 	}
+	format, err := exportFormat(req)
 	if err != nil {
-		logger.Log.Error("error occurred during getting all withdrawals", zap.Error(err))
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		renderError(w, req, err)
+		return
+	}
+	if format != "" {
+		h.exportWithdrawals(w, req, query, format)
 		return
 	}
-	w.Header().Set(contentType, applicationJSON)
-	if err = json.NewEncoder(w).Encode(withdrawals); err != nil {
-		logger.Log.Error("error encoding withdrawals", zap.Error(err))
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	page, err := h.service.GetAllWithdrawals(req.Context(), query)
+	if err != nil {
+		renderError(w, req, err)
+		return
 	}
+	if len(page.Withdrawals) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	setNextLink(w, req, page.NextCursor)
+	writeJSON(w, page.Withdrawals)
 }
 
 func (h *Handler) GetBalance(w http.ResponseWriter, req *http.Request) {
 	userID, err := getUserID(req)
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		renderError(w, req, err)
 		return
 	}
 	balance, err := h.service.GetBalance(req.Context(), userID)
 	if err != nil {
-		logger.Log.Error("error occurred during getting balance", zap.Error(err))
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		renderError(w, req, err)
+		return
+	}
+	writeJSON(w, balance)
+}
+
+func (h *Handler) GetStatement(w http.ResponseWriter, req *http.Request) {
+	userID, err := getUserID(req)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	var v errs.ValidationError
+	query := &domain.StatementQuery{
+		UserID: userID,
+		From:   parseTimeParam(&v, req.URL.Query().Get("from"), "from"),
+		To:     parseTimeParam(&v, req.URL.Query().Get("to"), "to"),
+	}
+	if err = v.Err(); err != nil {
+		renderError(w, req, err)
 		return
 	}
-	w.Header().Set(contentType, applicationJSON)
-	if err = json.NewEncoder(w).Encode(balance); err != nil {
-		logger.Log.Error("error encoding balance", zap.Error(err))
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	if err = validation.ValidateStatementQuery(query); err != nil {
+		renderError(w, req, err)
+		return
+	}
+	format, err := exportFormat(req)
+	if err != nil {
+		renderError(w, req, err)
+		return
 	}
+	if format != "" {
+		h.exportStatement(w, req, query, format)
+		return
+	}
+	statement, err := h.service.GetStatement(req.Context(), query)
+	if err != nil {
+		renderError(w, req, err)
+		return
+	}
+	writeJSON(w, statement)
 }
 
 func (h *Handler) WithdrawBonuses(w http.ResponseWriter, req *http.Request) {
 	var withdraw domain.WithdrawalIn
 	userID, err := getUserID(req)
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		renderError(w, req, err)
 		return
 	}
-	if err = json.NewDecoder(req.Body).Decode(&withdraw); err != nil {
-		logger.Log.Info("cannot decode withdraw JSON body", zap.Error(err))
-		w.WriteHeader(http.StatusBadRequest)
+	if err = decodeJSON(req, &withdraw); err != nil {
+		renderError(w, req, err)
 		return
 	}
 	if err = validation.ValidateWithdrawIn(&withdraw); err != nil {
-		logger.Log.Info("withdraw validation error", zap.Error(err))
-		w.WriteHeader(http.StatusBadRequest)
+		renderError(w, req, err)
 		return
 	}
-	err = h.service.WithdrawBonuses(req.Context(), userID, &withdraw)
-	if err == nil {
-		w.WriteHeader(http.StatusOK)
+	withdraw.OTPCode = req.Header.Get(otpCodeHeader)
+	if err = h.service.WithdrawBonuses(req.Context(), userID, &withdraw); err != nil {
+		renderError(w, req, err)
 		return
 	}
-	statusCode := http.StatusInternalServerError
-	switch {
-	case errors.Is(err, errs.ErrNotEnoughFunds):
-		statusCode = http.StatusPaymentRequired
-	case errors.Is(err, errs.ErrInvalidOrderNumber):
-		statusCode = http.StatusUnprocessableEntity
-	}
-	if statusCode == http.StatusInternalServerError {
-		logger.Log.Error("unexpected error occurred during creating order", zap.Error(err))
-	}
-	http.Error(w, http.StatusText(statusCode), statusCode)
+	w.WriteHeader(http.StatusOK)
 }
@@ -0,0 +1,49 @@
+package validation
+
+import (
+	"fmt"
+	"gophermart/internal/core/domain"
+	"gophermart/internal/errs"
+	"slices"
+	"time"
+)
+
+func ValidatePageQuery(query *domain.PageQuery) error {
+	var v errs.ValidationError
+	validatePage(&v, query)
+	return v.Err()
+}
+
+func ValidateOrderQuery(query *domain.OrderQuery) error {
+	var v errs.ValidationError
+	validatePage(&v, &query.PageQuery)
+	statuses := domain.OrderStatuses()
+	for _, status := range query.Statuses {
+		if !slices.Contains(statuses, status) {
+			v.Add("status", fmt.Sprintf("unknown status %q", status))
+		}
+	}
+	return v.Err()
+}
+
+func ValidateStatementQuery(query *domain.StatementQuery) error {
+	var v errs.ValidationError
+	validateRange(&v, query.From, query.To)
+	return v.Err()
+}
+
+func validatePage(v *errs.ValidationError, query *domain.PageQuery) {
+	if query.Limit < 0 || query.Limit > domain.MaxPageLimit {
+		v.Add("limit", fmt.Sprintf("must be between 1 and %d", domain.MaxPageLimit))
+	}
+	if query.Sort != "" && query.Sort != domain.SortAsc && query.Sort != domain.SortDesc {
+		v.Add("sort", "must be asc or desc")
+	}
+	validateRange(v, query.From, query.To)
+}
+
+func validateRange(v *errs.ValidationError, from, to *time.Time) {
+	if from != nil && to != nil && !from.Before(*to) {
+		v.Add("from", "must be before to")
+	}
+}
@@ -1,20 +1,122 @@
 package validation
 
 import (
+	"fmt"
 	"gophermart/internal/core/domain"
 	"gophermart/internal/errs"
+	"strings"
+)
+
+const (
+	msgRequired = "is required"
+	msgPositive = "must be greater than zero"
+	msgNonZero  = "must not be zero"
 )
 
 func ValidateUserIn(userIn *domain.UserIn) error {
-	if userIn == nil || userIn.Password == "" || userIn.Login == "" {
-		return errs.ErrValidationError
+	if userIn == nil {
+		return errs.NewFieldError("body", msgRequired)
 	}
-	return nil
+	var v errs.ValidationError
+	if userIn.Login == "" {
+		v.Add("login", msgRequired)
+	}
+	if userIn.Password == "" {
+		v.Add("password", msgRequired)
+	}
+	return v.Err()
 }
 
 func ValidateWithdrawIn(withdrawIn *domain.WithdrawalIn) error {
-	if withdrawIn == nil || withdrawIn.OrderNumber == "" || withdrawIn.Sum == 0.0 {
-		return errs.ErrValidationError
+	if withdrawIn == nil {
+		return errs.NewFieldError("body", msgRequired)
+	}
+	var v errs.ValidationError
+	if withdrawIn.OrderNumber == "" {
+		v.Add("order", msgRequired)
+	}
+	if withdrawIn.Sum <= 0 {
+		v.Add("sum", msgPositive)
+	}
+	return v.Err()
+}
+
+func ValidateBalanceAdjustmentIn(adjustmentIn *domain.BalanceAdjustmentIn) error {
+	if adjustmentIn == nil {
+		return errs.NewFieldError("body", msgRequired)
+	}
+	var v errs.ValidationError
+	if adjustmentIn.Sum == 0.0 {
+		v.Add("sum", msgNonZero)
+	}
+	if strings.TrimSpace(adjustmentIn.Reason) == "" {
+		v.Add("reason", msgRequired)
+	}
+	return v.Err()
+}
+
+func ValidateAPIKeyIn(keyIn *domain.APIKeyIn) error {
+	if keyIn == nil {
+		return errs.NewFieldError("body", msgRequired)
+	}
+	var v errs.ValidationError
+	if strings.TrimSpace(keyIn.Name) == "" {
+		v.Add("name", msgRequired)
+	}
+	if len(keyIn.Scopes) == 0 {
+		v.Add("scopes", msgRequired)
+	}
+	return v.Err()
+}
+
+func ValidateMFALoginIn(mfaIn *domain.MFALoginIn) error {
+	if mfaIn == nil {
+		return errs.NewFieldError("body", msgRequired)
+	}
+	var v errs.ValidationError
+	if mfaIn.MFAToken == "" {
+		v.Add("mfa_token", msgRequired)
+	}
+	if mfaIn.Code == "" {
+		v.Add("code", msgRequired)
+	}
+	return v.Err()
+}
+
+func ValidateTOTPCodeIn(codeIn *domain.TOTPCodeIn) error {
+	if codeIn == nil || codeIn.Code == "" {
+		return errs.NewFieldError("code", msgRequired)
+	}
+	return nil
+}
+
+func ValidatePasswordForgotIn(forgotIn *domain.PasswordForgotIn) error {
+	if forgotIn == nil || forgotIn.Login == "" {
+		return errs.NewFieldError("login", msgRequired)
+	}
+	return nil
+}
+
+func ValidatePasswordResetIn(resetIn *domain.PasswordResetIn) error {
+	if resetIn == nil {
+		return errs.NewFieldError("body", msgRequired)
+	}
+	var v errs.ValidationError
+	if resetIn.Token == "" {
+		v.Add("token", msgRequired)
+	}
+	if resetIn.Password == "" {
+		v.Add("password", msgRequired)
+	}
+	return v.Err()
+}
+
+func ValidateBulkOrders(numbers []string) error {
+	if len(numbers) == 0 {
+		return errs.NewFieldError("body", "must contain at least one order number")
+	}
+	if len(numbers) > domain.MaxBulkOrders {
+		return errs.NewFieldError("body", fmt.Sprintf("must contain at most %d order numbers", domain.MaxBulkOrders))
 	}
 	return nil
 }
@@ -3,44 +3,118 @@ package app
 import (
 	"context"
 	"fmt"
+	"gophermart/internal/adapters/api/admin"
+	"gophermart/internal/adapters/api/grpcapi"
 	"gophermart/internal/adapters/api/rest"
+	"gophermart/internal/adapters/health"
+	"gophermart/internal/adapters/mailer"
+	"gophermart/internal/adapters/ratelimit"
 	"gophermart/internal/adapters/storage"
+	"gophermart/internal/adapters/tlsconfig"
 	"gophermart/internal/config"
 	"gophermart/internal/core/accrual"
 	"gophermart/internal/core/service"
 	"gophermart/internal/logger"
+	"gophermart/internal/metrics"
+	"gophermart/internal/tracing"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/go-resty/resty/v2"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 )
 
+const tracingShutdownTimeout = 5 * time.Second
+
 type App struct {
-	accrual *accrual.Service
-	api     *rest.API
+	config          *config.Config
+	certs           *tlsconfig.Reloader
+	liability       *metrics.LiabilityCollector
+	shutdownTracing func(context.Context) error
+	health          *health.Health
+	accrual         *accrual.Service
+	service         *service.Service
+	api             *rest.API
+	grpcAPI         *grpcapi.API
+	adminAPI        *admin.API
 }
 
 func NewApp(cfg *config.Config) (*App, error) {
+	shutdownTracing, err := tracing.Initialize(context.Background(), &tracing.Options{
+		Exporter:    cfg.TraceExporter,
+		Endpoint:    cfg.TraceEndpoint,
+		Insecure:    cfg.TraceInsecure,
+		File:        cfg.TraceFile,
+		SampleRatio: cfg.TraceSampleRatio,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize tracing: %w", err)
+	}
 	activeStorage, err := storage.NewStorage(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize a storage: %w", err)
 	}
-	newService := service.NewService(cfg, activeStorage)
+	activeMailer, err := mailer.NewMailer(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize a mailer: %w", err)
+	}
+	newService := service.NewService(cfg, activeStorage, activeMailer)
 	accrualService := accrual.NewAccrualService(
 		activeStorage,
 		cfg,
-		resty.New(),
+		resty.New().SetTransport(otelhttp.NewTransport(http.DefaultTransport)),
 		accrual.NewWorkerTimeoutMap(cfg.AccrualRateLimit),
 	)
-	api := rest.NewAPI(cfg, newService)
+	limiter, err := ratelimit.NewStore(cfg, activeStorage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize a rate limit store: %w", err)
+	}
+	liability := metrics.NewLiabilityCollector(activeStorage.GetOutstandingLiability)
+	metrics.Registry.MustRegister(liability)
+	probe := health.New()
+	probe.Add("postgres", health.Ping(activeStorage))
+	probe.Add("migrations", health.Migrations(activeStorage))
+	probe.Add("accrual", health.Accrual(accrualService))
+	var certs *tlsconfig.Reloader
+	if cfg.TLSEnabled() {
+		if certs, err = tlsconfig.New(cfg); err != nil {
+			return nil, fmt.Errorf("failed to configure tls: %w", err)
+		}
+	}
+	api, err := rest.NewAPI(cfg, newService, limiter, probe, activeStorage, certs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize an api: %w", err)
+	}
+	var grpcAPI *grpcapi.API
+	if cfg.GRPCAddress != "" {
+		grpcAPI = grpcapi.NewAPI(cfg, newService, limiter, certs)
+	}
+	var adminAPI *admin.API
+	if cfg.AdminAddress != "" {
+		adminAPI = admin.NewAPI(cfg, probe)
+	}
 	return &App{
-		accrual: accrualService,
-		api:     api,
+		config:          cfg,
+		certs:           certs,
+		liability:       liability,
+		shutdownTracing: shutdownTracing,
+		health:          probe,
+		accrual:         accrualService,
+		service:         newService,
+		api:             api,
+		grpcAPI:         grpcAPI,
+		adminAPI:        adminAPI,
 	}, nil
 }
 
 func (a *App) Run() error {
-	ctx := context.Background()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go a.drainOnSignal(ctx, cancel)
 	g, ctx := errgroup.WithContext(ctx)
 	g.Go(func() error {
 		err := a.accrual.Run(ctx)
@@ -58,10 +132,74 @@ func (a *App) Run() error {
 		}
 		return nil
 	})
-	if err := g.Wait(); err != nil {
+	g.Go(func() error {
+		err := a.service.RunOrderEvents(ctx)
+		if err != nil {
+			logger.Log.Error("order events run failed:", zap.Error(err))
+			return fmt.Errorf("order events run failed: %w", err)
+		}
+		return nil
+	})
+	if a.grpcAPI != nil {
+		g.Go(func() error {
+			err := a.grpcAPI.Run(ctx)
+			if err != nil {
+				logger.Log.Error("grpc api run failed:", zap.Error(err))
+				return fmt.Errorf("grpc api run failed: %w", err)
+			}
+			return nil
+		})
+	}
+	g.Go(func() error {
+		a.liability.Run(ctx, time.Duration(a.config.LiabilityInterval)*time.Second)
+		return nil
+	})
+	if a.certs != nil {
+		g.Go(func() error {
+			a.certs.Watch(ctx)
+			return nil
+		})
+	}
+	if a.adminAPI != nil {
+		g.Go(func() error {
+			err := a.adminAPI.Run(ctx)
+			if err != nil {
+				logger.Log.Error("admin api run failed:", zap.Error(err))
+				return fmt.Errorf("admin api run failed: %w", err)
+			}
+			return nil
+		})
+	}
+	err := g.Wait()
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+	defer cancelShutdown()
+	if shutdownErr := a.shutdownTracing(shutdownCtx); shutdownErr != nil {
+		logger.Log.Error("tracing shutdown failed:", zap.Error(shutdownErr))
+	}
+	if err != nil {
 		logger.Log.Error("app run failed:", zap.Error(err))
 		return fmt.Errorf("app run failed: %w", err)
 	}
 	return nil
+}
 
+// drainOnSignal fails readiness on SIGINT or SIGTERM and gives the orchestrator
+// the drain period to stop routing traffic here before everything is stopped.
+func (a *App) drainOnSignal(ctx context.Context, stop context.CancelFunc) {
+	signals, release := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer release()
+	<-signals.Done()
+	if ctx.Err() != nil {
+		return
+	}
+	a.health.Drain()
+	drain := time.Duration(a.config.ShutdownDrain) * time.Second
+	logger.Log.Info("shutdown started, draining", zap.Duration("drain", drain))
+	timer := time.NewTimer(drain)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	stop()
 }
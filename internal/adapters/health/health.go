@@ -0,0 +1,159 @@
+// Package health runs the dependency checks behind the liveness and readiness probes.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gophermart/internal/core/accrual"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+
+	checkTimeout = 2 * time.Second
+)
+
+// CheckFunc probes one dependency and returns a short detail on success.
+type CheckFunc func(ctx context.Context) (string, error)
+
+type Check struct {
+	Status   string `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration,omitempty"`
+}
+
+type Report struct {
+	Status string           `json:"status"`
+	Checks map[string]Check `json:"checks"`
+}
+
+type Health struct {
+	checks   map[string]CheckFunc
+	draining atomic.Bool
+}
+
+func New() *Health {
+	return &Health{checks: make(map[string]CheckFunc)}
+}
+
+// Add registers a readiness check. It is not safe to call once probes are served.
+func (h *Health) Add(name string, check CheckFunc) {
+	h.checks[name] = check
+}
+
+// Drain marks the start of shutdown, so readiness fails while in-flight requests complete.
+func (h *Health) Drain() {
+	h.draining.Store(true)
+}
+
+// Ready runs all checks concurrently; the report is up only if every check is.
+func (h *Health) Ready(ctx context.Context) *Report {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	report := &Report{Status: StatusUp, Checks: make(map[string]Check, len(h.checks)+1)}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, check := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := run(ctx, check)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+		}()
+	}
+	wg.Wait()
+	shutdown := Check{Status: StatusUp, Duration: "0s"}
+	if h.draining.Load() {
+		shutdown.Status, shutdown.Error = StatusDown, "shutdown in progress"
+	}
+	report.Checks["shutdown"] = shutdown
+	for _, check := range report.Checks {
+		if check.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+// Summary is the report with only the status of each check, for callers that must not see
+// dependency details such as hosts or driver errors.
+func (r *Report) Summary() *Report {
+	summary := &Report{Status: r.Status, Checks: make(map[string]Check, len(r.Checks))}
+	for name, check := range r.Checks {
+		summary.Checks[name] = Check{Status: check.Status}
+	}
+	return summary
+}
+
+func run(ctx context.Context, check CheckFunc) Check {
+	start := time.Now()
+	detail, err := check(ctx)
+	result := Check{Status: StatusUp, Detail: detail, Duration: time.Since(start).String()}
+	if err != nil {
+		result.Status, result.Error = StatusDown, err.Error()
+	}
+	return result
+}
+
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Ping turns a dependency's Ping into a check.
+func Ping(p pinger) CheckFunc {
+	return func(ctx context.Context) (string, error) {
+		if err := p.Ping(ctx); err != nil {
+			return "", fmt.Errorf("%w", err)
+		}
+		return "", nil
+	}
+}
+
+type migrator interface {
+	MigrationVersion(ctx context.Context) (current, latest int64, err error)
+}
+
+// Migrations fails until the database runs the latest schema the binary ships with.
+func Migrations(m migrator) CheckFunc {
+	return func(ctx context.Context) (string, error) {
+		current, latest, err := m.MigrationVersion(ctx)
+		if err != nil {
+			return "", fmt.Errorf("%w", err)
+		}
+		detail := fmt.Sprintf("version %d of %d", current, latest)
+		if current < latest {
+			return detail, fmt.Errorf("database is behind: %s", detail)
+		}
+		return detail, nil
+	}
+}
+
+type circuitPinger interface {
+	pinger
+	CircuitState() string
+}
+
+// Accrual requires the accrual system to answer and the circuit towards it not to be open.
+func Accrual(a circuitPinger) CheckFunc {
+	return func(ctx context.Context) (string, error) {
+		state := a.CircuitState()
+		detail := "circuit " + state
+		if err := a.Ping(ctx); err != nil {
+			return detail, fmt.Errorf("%w", err)
+		}
+		if state == accrual.CircuitOpen {
+			return detail, errors.New("circuit is open")
+		}
+		return detail, nil
+	}
+}
@@ -0,0 +1,38 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"gophermart/internal/core/domain"
+	"gophermart/internal/logger"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const mailFilePerm = 0o600
+
+// LocalMailer logs the recipient and subject of messages and, when dir is set, writes each of them
+// to a file there. Bodies are never logged, as they can carry secrets such as reset tokens.
+type LocalMailer struct {
+	dir string
+}
+
+func NewLocalMailer(dir string) *LocalMailer {
+	return &LocalMailer{dir: dir}
+}
+
+func (m *LocalMailer) Send(_ context.Context, msg *domain.Message) error {
+	logger.Log.Info("mail sent", zap.String("to", msg.To), zap.String("subject", msg.Subject))
+	if m.dir == "" {
+		return nil
+	}
+	name := filepath.Join(m.dir, fmt.Sprintf("%d.eml", time.Now().UnixNano()))
+	content := fmt.Sprintf("To: %s\nSubject: %s\n\n%s\n", msg.To, msg.Subject, msg.Body)
+	if err := os.WriteFile(name, []byte(content), mailFilePerm); err != nil {
+		return fmt.Errorf("failed to write mail file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"gophermart/internal/config"
+	"gophermart/internal/core/domain"
+)
+
+const (
+	TypeLog  = "log"
+	TypeSMTP = "smtp"
+)
+
+type Mailer interface {
+	Send(ctx context.Context, msg *domain.Message) error
+}
+
+func NewMailer(cfg *config.Config) (Mailer, error) {
+	switch cfg.MailerType {
+	case TypeSMTP:
+		return NewSMTPMailer(cfg.SMTPAddress, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom), nil
+	case TypeLog, "":
+		return NewLocalMailer(cfg.MailDir), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer type: %s", cfg.MailerType)
+	}
+}
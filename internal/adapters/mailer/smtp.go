@@ -0,0 +1,108 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"gophermart/internal/core/domain"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+var headerSanitizer = strings.NewReplacer("\r", "", "\n", "")
+
+type SMTPMailer struct {
+	address  string
+	username string
+	password string
+	from     string
+}
+
+func NewSMTPMailer(address, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{address: address, username: username, password: password, from: from}
+}
+
+// Send talks to the server within ctx: the connection gets the deadline of ctx and is cut off when
+// ctx is cancelled, so a stuck server can't hold the caller. It upgrades to TLS when the server offers it.
+func (m *SMTPMailer) Send(ctx context.Context, msg *domain.Message) error {
+	host, _, err := net.SplitHostPort(m.address)
+	if err != nil {
+		return fmt.Errorf("invalid smtp address: %w", err)
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", m.address)
+	if err != nil {
+		return fmt.Errorf("failed to dial smtp server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err = conn.SetDeadline(deadline); err != nil {
+			_ = conn.Close()
+			return fmt.Errorf("failed to set smtp deadline: %w", err)
+		}
+	}
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetDeadline(time.Now())
+	})
+	defer stop()
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("failed to start smtp session: %w", err)
+	}
+	defer client.Close()
+	if err = m.send(client, host, msg); err != nil {
+		return err
+	}
+	if err = client.Quit(); err != nil {
+		return fmt.Errorf("failed to end smtp session: %w", err)
+	}
+	return nil
+}
+
+// send is smtp.SendMail over an established client.
+func (m *SMTPMailer) send(client *smtp.Client, host string, msg *domain.Message) error {
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("failed to start tls with smtp server: %w", err)
+		}
+	}
+	if m.username != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("smtp server does not support authentication")
+		}
+		if err := client.Auth(smtp.PlainAuth("", m.username, m.password, host)); err != nil {
+			return fmt.Errorf("failed to authenticate with smtp server: %w", err)
+		}
+	}
+	if err := client.Mail(m.from); err != nil {
+		return fmt.Errorf("smtp server rejected the sender: %w", err)
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return fmt.Errorf("smtp server rejected the recipient: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to start smtp data: %w", err)
+	}
+	if _, err = w.Write(m.format(msg)); err != nil {
+		return fmt.Errorf("failed to write mail via smtp: %w", err)
+	}
+	if err = w.Close(); err != nil {
+		return fmt.Errorf("failed to send mail via smtp: %w", err)
+	}
+	return nil
+}
+
+func (m *SMTPMailer) format(msg *domain.Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + headerSanitizer.Replace(m.from) + "\r\n")
+	b.WriteString("To: " + headerSanitizer.Replace(msg.To) + "\r\n")
+	b.WriteString("Subject: " + headerSanitizer.Replace(msg.Subject) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(msg.Body)
+	return []byte(b.String())
+}
@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"context"
+	"gophermart/internal/core/domain"
+	"sync"
+	"time"
+)
+
+type counter struct {
+	start time.Time
+	hits  int
+}
+
+// MemoryStore keeps the counters of a single instance.
+type MemoryStore struct {
+	mu        sync.Mutex
+	counters  map[string]*counter
+	nextPrune time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: make(map[string]*counter)}
+}
+
+func (m *MemoryStore) Take(
+	_ context.Context,
+	key string,
+	limit int,
+	window time.Duration,
+) (*domain.RateLimitResult, error) {
+	now := time.Now()
+	start := now.Truncate(window)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if now.After(m.nextPrune) {
+		m.prune(start)
+		m.nextPrune = now.Add(window)
+	}
+	c, ok := m.counters[key]
+	if !ok || !c.start.Equal(start) {
+		c = &counter{start: start}
+		m.counters[key] = c
+	}
+	c.hits++
+	return domain.NewRateLimitResult(limit, c.hits, start.Add(window)), nil
+}
+
+// prune drops counters of windows that ended before the current one started.
+func (m *MemoryStore) prune(current time.Time) {
+	for key, c := range m.counters {
+		if c.start.Before(current) {
+			delete(m.counters, key)
+		}
+	}
+}
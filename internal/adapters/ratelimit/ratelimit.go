@@ -0,0 +1,72 @@
+// Package ratelimit counts requests per key in fixed windows, either in process or in Postgres.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"gophermart/internal/adapters/storage"
+	"gophermart/internal/config"
+	"gophermart/internal/core/domain"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	TypeMemory   = "memory"
+	TypePostgres = "postgres"
+)
+
+// GroupAuth is the sign-in group, shared by the REST and gRPC APIs so that switching transports gains nothing.
+const GroupAuth = "auth"
+
+type Store interface {
+	Take(ctx context.Context, key string, limit int, window time.Duration) (*domain.RateLimitResult, error)
+}
+
+// NewStore picks the configured store. The Postgres one shares counters between instances.
+func NewStore(cfg *config.Config, pgStorage storage.RateLimit) (Store, error) {
+	switch cfg.RateLimitStore {
+	case TypeMemory, "":
+		return NewMemoryStore(), nil
+	case TypePostgres:
+		return &postgresStore{storage: pgStorage}, nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit store: %s", cfg.RateLimitStore)
+	}
+}
+
+// ClientIP picks the address anonymous callers are counted by. Without a configured header that is the peer
+// address, which puts every client behind a load balancer into one bucket. Behind a trusted proxy the header
+// names where it puts the client address; for lists such as X-Forwarded-For the entry the proxy appended,
+// the last one, is taken because everything before it comes from the client.
+func ClientIP(peer, forwarded string) string {
+	if forwarded != "" {
+		forwarded = forwarded[strings.LastIndex(forwarded, ",")+1:]
+		if ip := strings.TrimSpace(forwarded); ip != "" {
+			return ip
+		}
+	}
+	host, _, err := net.SplitHostPort(peer)
+	if err != nil {
+		return peer
+	}
+	return host
+}
+
+type postgresStore struct {
+	storage storage.RateLimit
+}
+
+func (p *postgresStore) Take(
+	ctx context.Context,
+	key string,
+	limit int,
+	window time.Duration,
+) (*domain.RateLimitResult, error) {
+	result, err := p.storage.TakeRateLimit(ctx, key, limit, window)
+	if err != nil {
+		return nil, fmt.Errorf("failed to take rate limit: %w", err)
+	}
+	return result, nil
+}
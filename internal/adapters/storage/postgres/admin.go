@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gophermart/internal/core/domain"
+	"gophermart/internal/errs"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func (s *Storage) GetUserByID(ctx context.Context, userID int) (*domain.UserOut, error) {
+	return s.getUserOut(ctx, getUserByIDSQL, userID)
+}
+
+func (s *Storage) GetUserByLogin(ctx context.Context, login string) (*domain.UserOut, error) {
+	return s.getUserOut(ctx, getUserByLoginSQL, login)
+}
+
+func (s *Storage) getUserOut(ctx context.Context, query string, arg any) (*domain.UserOut, error) {
+	var user domain.UserOut
+	row := s.db.QueryRow(ctx, query, arg)
+	if err := row.Scan(&user.ID, &user.Login, &user.Role, &user.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get user in PG: %w", err)
+	}
+	return &user, nil
+}
+
+// RequeueOrder resets the order to NEW and writes audit in the same transaction.
+func (s *Storage) RequeueOrder(ctx context.Context, number string, audit *domain.AuditRecord) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer s.rollback(ctx, tx)
+	tag, err := tx.Exec(ctx, requeueOrderSQL, domain.New, time.Now(), number, domain.Processed)
+	if err != nil {
+		return fmt.Errorf("failed to requeue order in PG: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errs.ErrNotFound
+	}
+	if err = createAuditRecord(ctx, tx, audit); err != nil {
+		return err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction %w", err)
+	}
+	return nil
+}
+
+// AdjustBalance records the adjustment and audit in one transaction. It holds the user lock taken by
+// withdrawals as well, so that a negative adjustment and a withdrawal can't overdraw the balance together.
+func (s *Storage) AdjustBalance(
+	ctx context.Context,
+	userID int,
+	adjustment *domain.BalanceAdjustmentIn,
+	audit *domain.AuditRecord,
+) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer s.rollback(ctx, tx)
+	if err = lockUser(ctx, tx, userID); err != nil {
+		return err
+	}
+	if adjustment.Sum < 0 {
+		balance, err := s.getBalance(ctx, tx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get balance for balance adjustment: %w", err)
+		}
+		if balance.Current+adjustment.Sum < 0 {
+			return errs.ErrNotEnoughFunds
+		}
+	}
+	amount := toMinorUnits(adjustment.Sum)
+	if _, err = tx.Exec(ctx, createBalanceAdjustmentSQL, userID, audit.AdminID, amount, adjustment.Reason); err != nil {
+		return fmt.Errorf("failed to create balance adjustment: %w", err)
+	}
+	if err = createAuditRecord(ctx, tx, audit); err != nil {
+		return err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction %w", err)
+	}
+	return nil
+}
+
+// lockUser serialises balance changes of the user until tx ends.
+func lockUser(ctx context.Context, tx pgx.Tx, userID int) error {
+	if err := tx.QueryRow(ctx, lockUserSQL, userID).Scan(&userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errs.ErrNotFound
+		}
+		return fmt.Errorf("failed to lock user: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) CreateAuditRecord(ctx context.Context, record *domain.AuditRecord) error {
+	return createAuditRecord(ctx, s.db, record)
+}
+
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+func createAuditRecord(ctx context.Context, db execer, record *domain.AuditRecord) error {
+	_, err := db.Exec(
+		ctx,
+		createAuditRecordSQL,
+		record.AdminID,
+		record.Action,
+		record.TargetUserID,
+		record.Details,
+		record.SessionID,
+		record.AuthMethod,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create audit record in PG: %w", err)
+	}
+	return nil
+}
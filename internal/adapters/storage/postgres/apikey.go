@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gophermart/internal/core/domain"
+	"gophermart/internal/errs"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *Storage) CreateAPIKey(ctx context.Context, key *domain.APIKey) error {
+	row := s.db.QueryRow(ctx, createAPIKeySQL, key.UserID, key.Name, key.Prefix, key.KeyHash, key.Scopes)
+	if err := row.Scan(&key.ID, &key.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create api key in PG: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) GetAllAPIKeys(ctx context.Context, userID int) (domain.APIKeyList, error) {
+	rows, err := s.db.Query(ctx, getAllAPIKeysSQL, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api keys in PG: %w", err)
+	}
+	defer rows.Close()
+	keys := make(domain.APIKeyList, 0)
+	for rows.Next() {
+		var key domain.APIKey
+		if err = scanAPIKey(rows, &key); err != nil {
+			return nil, fmt.Errorf("failed to parse api key in PG: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan api key rows: %w", err)
+	}
+	return keys, nil
+}
+
+func (s *Storage) RevokeAPIKey(ctx context.Context, userID, keyID int) error {
+	tag, err := s.db.Exec(ctx, revokeAPIKeySQL, time.Now(), keyID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key in PG: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errs.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Storage) UseAPIKey(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	var key domain.APIKey
+	if err := scanAPIKey(s.db.QueryRow(ctx, useAPIKeySQL, time.Now(), keyHash), &key); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to use api key in PG: %w", err)
+	}
+	return &key, nil
+}
+
+func scanAPIKey(row pgx.Row, key *domain.APIKey) error {
+	err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.Scopes, &key.CreatedAt, &key.LastUsedAt)
+	if err != nil {
+		return fmt.Errorf("failed to scan api key: %w", err)
+	}
+	return nil
+}
@@ -2,29 +2,32 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 	"gophermart/internal/core/domain"
 	"gophermart/internal/errs"
+	"gophermart/internal/logger"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
 )
 
 const (
 	PGUniqueViolationCode = "23505"
 )
 
-func (s *Storage) GetUserID(ctx context.Context, user *domain.UserIn) (int, error) {
-	var id int
-	row := s.db.QueryRow(ctx, getUserIDSQL, user.Login, user.PasswordHash)
-	if err := row.Scan(&id); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return 0, errs.ErrInvalidLoginOrPassword
+func (s *Storage) GetUser(ctx context.Context, user *domain.UserIn) (*domain.User, error) {
+	var userOut domain.User
+	row := s.db.QueryRow(ctx, getUserSQL, user.Login, user.PasswordHash)
+	if err := row.Scan(&userOut.ID, &userOut.Login, &userOut.Role, &userOut.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.ErrInvalidLoginOrPassword
 		}
-		return 0, fmt.Errorf("failed to query user: %w", err)
+		return nil, fmt.Errorf("failed to query user: %w", err)
 	}
-	return id, nil
+	return &userOut, nil
 }
 
 func (s *Storage) CreateUser(ctx context.Context, user *domain.UserIn) error {
@@ -42,3 +45,24 @@ func (s *Storage) CreateUser(ctx context.Context, user *domain.UserIn) error {
 	}
 	return nil
 }
+
+// UseMFAToken marks the MFA token tokenID as used until it expires. A used token returns ErrNotFound.
+// Expired tokens are pruned on the way, at most once per token lifetime.
+func (s *Storage) UseMFAToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	now := time.Now().UTC()
+	tag, err := s.db.Exec(ctx, useMFATokenSQL, tokenID, expiresAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to use mfa token in PG: %w", err)
+	}
+	if next := s.nextMFATokenPrune.Load(); next == nil || now.After(*next) {
+		pruneAt := expiresAt.UTC()
+		s.nextMFATokenPrune.Store(&pruneAt)
+		if _, err = s.db.Exec(ctx, deleteMFATokensBeforeSQL, now); err != nil {
+			logger.FromContext(ctx).Error("failed to prune used mfa tokens", zap.Error(err))
+		}
+	}
+	if tag.RowsAffected() == 0 {
+		return errs.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"gophermart/internal/core/domain"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	orderEventsChannel = "order_events"
+	// pgTimestampLayout is how row_to_json renders timestamp without time zone.
+	pgTimestampLayout = "2006-01-02T15:04:05.999999"
+)
+
+// orderEventPayload mirrors the order_events row sent by the publish_order_event trigger.
+type orderEventPayload struct {
+	ID        int64  `json:"id"`
+	UserID    int    `json:"user_id"`
+	Number    string `json:"number"`
+	Status    string `json:"status"`
+	Accrual   *int64 `json:"accrual"`
+	CreatedAt string `json:"created_at"`
+}
+
+// ListenOrderEvents holds a dedicated connection listening on the order_events channel
+// and sends every notification to events until ctx is done or the connection fails.
+// listening is called once the channel is listened on, events committed after that are not missed.
+func (s *Storage) ListenOrderEvents(
+	ctx context.Context,
+	events chan<- domain.OrderEvent,
+	listening func(),
+) error {
+	pooled, err := s.db.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire listen connection: %w", err)
+	}
+	// The connection keeps its LISTEN state, so it is taken out of the pool for good.
+	conn := pooled.Hijack()
+	defer func() {
+		_ = conn.Close(context.Background())
+	}()
+	if _, err = conn.Exec(ctx, "LISTEN "+orderEventsChannel); err != nil {
+		return fmt.Errorf("failed to listen for order events: %w", err)
+	}
+	listening()
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to wait for order events: %w", err)
+		}
+		event, err := parseOrderEvent(notification.Payload)
+		if err != nil {
+			return err
+		}
+		select {
+		case events <- *event:
+		case <-ctx.Done():
+			return fmt.Errorf("stopped listening for order events: %w", ctx.Err())
+		}
+	}
+}
+
+func parseOrderEvent(payload string) (*domain.OrderEvent, error) {
+	var raw orderEventPayload
+	if err := json.Unmarshal([]byte(payload), &raw); err != nil {
+		return nil, fmt.Errorf("failed to decode order event: %w", err)
+	}
+	createdAt, err := time.Parse(pgTimestampLayout, raw.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse order event time: %w", err)
+	}
+	event := &domain.OrderEvent{
+		ID:        raw.ID,
+		UserID:    raw.UserID,
+		Number:    raw.Number,
+		Status:    raw.Status,
+		CreatedAt: createdAt,
+	}
+	if raw.Accrual != nil {
+		value := float32(*raw.Accrual) / accrualFactor
+		event.Accrual = &value
+	}
+	return event, nil
+}
+
+func (s *Storage) GetOrderEventsSince(
+	ctx context.Context,
+	userID int,
+	afterID int64,
+	limit int,
+) ([]domain.OrderEvent, error) {
+	rows, err := s.db.Query(ctx, getOrderEventsSinceSQL, userID, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order events in PG: %w", err)
+	}
+	events, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.OrderEvent, error) {
+		var (
+			event   domain.OrderEvent
+			accrual sql.NullInt64
+		)
+		err := row.Scan(&event.ID, &event.UserID, &event.Number, &event.Status, &accrual, &event.CreatedAt)
+		if err != nil {
+			return event, fmt.Errorf("failed to scan order event: %w", err)
+		}
+		if accrual.Valid {
+			value := float32(accrual.Int64) / accrualFactor
+			event.Accrual = &value
+		}
+		return event, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order events in PG: %w", err)
+	}
+	return events, nil
+}
+
+func (s *Storage) DeleteOrderEventsBefore(ctx context.Context, before time.Time) error {
+	if _, err := s.db.Exec(ctx, deleteOrderEventsBeforeSQL, before.UTC()); err != nil {
+		return fmt.Errorf("failed to delete order events in PG: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pressly/goose/v3"
+)
+
+func (s *Storage) Ping(ctx context.Context) error {
+	if err := s.db.Ping(ctx); err != nil {
+		return fmt.Errorf("failed to ping PG: %w", err)
+	}
+	return nil
+}
+
+// MigrationVersion returns the version applied to the database and the latest one embedded in the binary.
+func (s *Storage) MigrationVersion(ctx context.Context) (current, latest int64, err error) {
+	if err = s.db.QueryRow(ctx, getMigrationVersionSQL).Scan(&current); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return 0, 0, fmt.Errorf("failed to get migration version from PG: %w", err)
+	}
+	entries, err := fs.ReadDir(migrations, "migrations")
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read embedded migrations: %w", err)
+	}
+	for _, entry := range entries {
+		version, parseErr := goose.NumericComponent(entry.Name())
+		if parseErr == nil && version > latest {
+			latest = version
+		}
+	}
+	return current, latest, nil
+}
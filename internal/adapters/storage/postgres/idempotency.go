@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gophermart/internal/core/domain"
+	"gophermart/internal/errs"
+	"gophermart/internal/logger"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// ReserveIdempotencyKey claims key for the request with fingerprint until ttl passes. When the key is taken
+// it returns the record of whoever used it first instead. Expired keys are pruned at most once per ttl.
+func (s *Storage) ReserveIdempotencyKey(
+	ctx context.Context,
+	userID int,
+	key, fingerprint string,
+	ttl time.Duration,
+) (*domain.IdempotencyRecord, bool, error) {
+	now := time.Now().UTC()
+	s.pruneIdempotencyKeys(ctx, now, ttl)
+	// A record released between the two queries is claimed on the second round.
+	for range 2 {
+		var reserved bool
+		err := s.db.QueryRow(ctx, reserveIdempotencyKeySQL, userID, key, fingerprint, now.Add(ttl), now).Scan(&reserved)
+		if err == nil {
+			return nil, true, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, fmt.Errorf("failed to reserve idempotency key in PG: %w", err)
+		}
+		record, err := s.getIdempotencyKey(ctx, userID, key)
+		if err == nil {
+			return record, false, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, fmt.Errorf("failed to get idempotency key from PG: %w", err)
+		}
+	}
+	return nil, false, errs.ErrIdempotencyKeyInUse
+}
+
+func (s *Storage) getIdempotencyKey(ctx context.Context, userID int, key string) (*domain.IdempotencyRecord, error) {
+	var (
+		record  domain.IdempotencyRecord
+		status  *int
+		headers map[string][]string
+		body    []byte
+	)
+	err := s.db.QueryRow(ctx, getIdempotencyKeySQL, userID, key).Scan(&record.Fingerprint, &status, &headers, &body)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // wrapped by the caller
+	}
+	if status != nil {
+		record.Response = &domain.IdempotentResponse{Status: *status, Header: headers, Body: body}
+	}
+	return &record, nil
+}
+
+func (s *Storage) SaveIdempotentResponse(
+	ctx context.Context,
+	userID int,
+	key string,
+	response *domain.IdempotentResponse,
+) error {
+	_, err := s.db.Exec(ctx, saveIdempotentResponseSQL, userID, key, response.Status, response.Header, response.Body)
+	if err != nil {
+		return fmt.Errorf("failed to save idempotent response in PG: %w", err)
+	}
+	return nil
+}
+
+// DeleteIdempotencyKey releases a key whose request failed, so that a retry runs again.
+func (s *Storage) DeleteIdempotencyKey(ctx context.Context, userID int, key string) error {
+	if _, err := s.db.Exec(ctx, deleteIdempotencyKeySQL, userID, key); err != nil {
+		return fmt.Errorf("failed to delete idempotency key in PG: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) pruneIdempotencyKeys(ctx context.Context, now time.Time, ttl time.Duration) {
+	if next := s.nextIdempotencyPrune.Load(); next != nil && !now.After(*next) {
+		return
+	}
+	pruneAt := now.Add(ttl)
+	s.nextIdempotencyPrune.Store(&pruneAt)
+	if _, err := s.db.Exec(ctx, deleteIdempotencyKeysBeforeSQL, now); err != nil {
+		logger.FromContext(ctx).Error("failed to prune idempotency keys", zap.Error(err))
+	}
+}
@@ -0,0 +1,15 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+)
+
+// GetOutstandingLiability sums the current balances of all users.
+func (s *Storage) GetOutstandingLiability(ctx context.Context) (float64, error) {
+	var liability int64
+	if err := s.db.QueryRow(ctx, getOutstandingLiabilitySQL).Scan(&liability); err != nil {
+		return 0, fmt.Errorf("failed to get outstanding liability in PG: %w", err)
+	}
+	return float64(liability) / accrualFactor, nil
+}
@@ -0,0 +1,31 @@
+-- +goose Up
+-- Roles: promote operators with UPDATE users SET role = 'admin' WHERE login = '...'
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS role VARCHAR(32) NOT NULL DEFAULT 'user';
+
+-- Manual balance adjustments made by operators
+CREATE TABLE IF NOT EXISTS balance_adjustments
+(
+    id         SERIAL PRIMARY KEY,
+    user_id    INT                         NOT NULL REFERENCES users (id),
+    admin_id   INT                         NOT NULL REFERENCES users (id),
+    amount     BIGINT                      NOT NULL,
+    reason     TEXT                        NOT NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT (current_timestamp AT TIME ZONE 'UTC')
+);
+CREATE INDEX IF NOT EXISTS balance_adjustments_user_id_idx ON balance_adjustments (user_id);
+
+-- Audit log of admin actions
+CREATE TABLE IF NOT EXISTS audit_log
+(
+    id             SERIAL PRIMARY KEY,
+    admin_id       INT                         NOT NULL REFERENCES users (id),
+    action         VARCHAR(64)                 NOT NULL,
+    target_user_id INT,
+    details        TEXT                        NOT NULL DEFAULT '',
+    created_at     timestamp without time zone NOT NULL DEFAULT (current_timestamp AT TIME ZONE 'UTC')
+);
+-- +goose Down
+DROP TABLE audit_log;
+DROP TABLE balance_adjustments;
+ALTER TABLE users DROP COLUMN role;
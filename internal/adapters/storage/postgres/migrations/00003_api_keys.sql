@@ -0,0 +1,17 @@
+-- +goose Up
+-- API keys for machine-to-machine access
+CREATE TABLE IF NOT EXISTS api_keys
+(
+    id           SERIAL PRIMARY KEY,
+    user_id      INT                         NOT NULL REFERENCES users (id),
+    name         VARCHAR(255)                NOT NULL,
+    prefix       VARCHAR(32)                 NOT NULL,
+    key_hash     VARCHAR(255)                NOT NULL UNIQUE,
+    scopes       TEXT[]                      NOT NULL,
+    created_at   timestamp without time zone NOT NULL DEFAULT (current_timestamp AT TIME ZONE 'UTC'),
+    last_used_at timestamp without time zone,
+    revoked_at   timestamp without time zone
+);
+CREATE INDEX IF NOT EXISTS api_keys_user_id_idx ON api_keys (user_id);
+-- +goose Down
+DROP TABLE api_keys;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- TOTP two-factor authentication
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS totp_secret  VARCHAR(64),
+    ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- One-time recovery codes for users with enabled TOTP
+CREATE TABLE IF NOT EXISTS totp_recovery_codes
+(
+    id         SERIAL PRIMARY KEY,
+    user_id    INT                         NOT NULL REFERENCES users (id),
+    code_hash  VARCHAR(255)                NOT NULL,
+    used_at    timestamp without time zone,
+    created_at timestamp without time zone NOT NULL DEFAULT (current_timestamp AT TIME ZONE 'UTC')
+);
+CREATE INDEX IF NOT EXISTS totp_recovery_codes_user_id_idx ON totp_recovery_codes (user_id);
+-- +goose Down
+DROP TABLE totp_recovery_codes;
+ALTER TABLE users DROP COLUMN totp_enabled, DROP COLUMN totp_secret;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Session metadata of the principal performing an admin action
+ALTER TABLE audit_log
+    ADD COLUMN IF NOT EXISTS session_id  VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS auth_method VARCHAR(32) NOT NULL DEFAULT '';
+-- +goose Down
+ALTER TABLE audit_log DROP COLUMN auth_method, DROP COLUMN session_id;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Single-use password reset tokens
+CREATE TABLE IF NOT EXISTS password_reset_tokens
+(
+    id         SERIAL PRIMARY KEY,
+    user_id    INT                         NOT NULL REFERENCES users (id),
+    token_hash VARCHAR(255)                NOT NULL UNIQUE,
+    expires_at timestamp without time zone NOT NULL,
+    used_at    timestamp without time zone,
+    created_at timestamp without time zone NOT NULL DEFAULT (current_timestamp AT TIME ZONE 'UTC')
+);
+CREATE INDEX IF NOT EXISTS password_reset_tokens_user_id_idx ON password_reset_tokens (user_id);
+-- +goose Down
+DROP TABLE password_reset_tokens;
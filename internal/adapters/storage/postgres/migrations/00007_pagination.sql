@@ -0,0 +1,5 @@
+-- +goose Up
+-- Keyset pagination over a user's orders and withdrawals
+CREATE INDEX IF NOT EXISTS orders_user_created_idx ON orders (user_id, created_at, id);
+-- +goose Down
+DROP INDEX IF EXISTS orders_user_created_idx;
@@ -0,0 +1,39 @@
+-- +goose Up
+-- Order status changes, kept for Last-Event-ID resume and broadcast with NOTIFY
+CREATE TABLE IF NOT EXISTS order_events
+(
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    INT                         NOT NULL REFERENCES users (id),
+    number     VARCHAR                     NOT NULL,
+    status     VARCHAR                     NOT NULL,
+    accrual    BIGINT,
+    created_at timestamp without time zone NOT NULL DEFAULT (current_timestamp AT TIME ZONE 'UTC')
+);
+CREATE INDEX IF NOT EXISTS order_events_user_id_idx ON order_events (user_id, id);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION publish_order_event() RETURNS trigger AS
+$$
+DECLARE
+    event order_events%ROWTYPE;
+BEGIN
+    INSERT INTO order_events (user_id, number, status, accrual)
+    VALUES (NEW.user_id, NEW.number, NEW.status, NEW.accrual)
+    RETURNING * INTO event;
+    PERFORM pg_notify('order_events', row_to_json(event)::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER orders_publish_event
+    AFTER UPDATE OF status, accrual
+    ON orders
+    FOR EACH ROW
+    WHEN (NEW.withdraw IS NULL AND
+          (OLD.status IS DISTINCT FROM NEW.status OR OLD.accrual IS DISTINCT FROM NEW.accrual))
+EXECUTE FUNCTION publish_order_event();
+-- +goose Down
+DROP TRIGGER IF EXISTS orders_publish_event ON orders;
+DROP FUNCTION IF EXISTS publish_order_event();
+DROP TABLE order_events;
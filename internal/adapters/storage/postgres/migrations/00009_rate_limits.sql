@@ -0,0 +1,11 @@
+-- +goose Up
+-- Fixed-window request counters shared by all instances
+CREATE TABLE IF NOT EXISTS rate_limits
+(
+    key          VARCHAR(255)                NOT NULL PRIMARY KEY,
+    window_start timestamp without time zone NOT NULL,
+    hits         INT                         NOT NULL
+);
+CREATE INDEX IF NOT EXISTS rate_limits_window_start_idx ON rate_limits (window_start);
+-- +goose Down
+DROP TABLE rate_limits;
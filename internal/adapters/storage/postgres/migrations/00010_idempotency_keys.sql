@@ -0,0 +1,16 @@
+-- +goose Up
+-- First response per user and Idempotency-Key, replayed on retries until it expires
+CREATE TABLE IF NOT EXISTS idempotency_keys
+(
+    user_id     INT                         NOT NULL REFERENCES users (id),
+    key         VARCHAR(255)                NOT NULL,
+    fingerprint VARCHAR(64)                 NOT NULL,
+    status      INT,
+    headers     JSONB,
+    body        BYTEA,
+    expires_at  timestamp without time zone NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
+-- +goose Down
+DROP TABLE idempotency_keys;
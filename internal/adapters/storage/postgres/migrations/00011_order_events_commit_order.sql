@@ -0,0 +1,33 @@
+-- +goose Up
+-- Events of a user are numbered under a per-user lock held until commit, so their ids follow commit
+-- and NOTIFY order and resuming after the last seen id can't skip an event committed late.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION publish_order_event() RETURNS trigger AS
+$$
+DECLARE
+    event order_events%ROWTYPE;
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtext('order_events'), NEW.user_id);
+    INSERT INTO order_events (user_id, number, status, accrual)
+    VALUES (NEW.user_id, NEW.number, NEW.status, NEW.accrual)
+    RETURNING * INTO event;
+    PERFORM pg_notify('order_events', row_to_json(event)::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION publish_order_event() RETURNS trigger AS
+$$
+DECLARE
+    event order_events%ROWTYPE;
+BEGIN
+    INSERT INTO order_events (user_id, number, status, accrual)
+    VALUES (NEW.user_id, NEW.number, NEW.status, NEW.accrual)
+    RETURNING * INTO event;
+    PERFORM pg_notify('order_events', row_to_json(event)::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- Time step of the last accepted TOTP code, so that a code is accepted once
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS totp_last_counter BIGINT NOT NULL DEFAULT 0;
+
+-- MFA tokens that completed a login, kept until they expire
+CREATE TABLE IF NOT EXISTS used_mfa_tokens
+(
+    token_id   VARCHAR(64)                 NOT NULL PRIMARY KEY,
+    expires_at timestamp without time zone NOT NULL
+);
+CREATE INDEX IF NOT EXISTS used_mfa_tokens_expires_at_idx ON used_mfa_tokens (expires_at);
+-- +goose Down
+DROP TABLE used_mfa_tokens;
+ALTER TABLE users DROP COLUMN totp_last_counter;
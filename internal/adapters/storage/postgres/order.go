@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"gophermart/internal/core/domain"
 	"gophermart/internal/errs"
+	"math"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -14,6 +15,12 @@ import (
 
 const accrualFactor = 100
 
+// toMinorUnits converts an amount to the hundredths stored in the database. It rounds rather than
+// truncates, since a float32 such as 0.29 is slightly below the decimal it stands for.
+func toMinorUnits(sum float32) int64 {
+	return int64(math.Round(float64(sum) * accrualFactor))
+}
+
 func (s *Storage) CreateOrder(ctx context.Context, userID int, order *domain.OrderIn) error {
 	_, err := s.db.Exec(ctx, createOrderSQL, userID, order.Number, domain.New)
 	if err != nil {
@@ -22,10 +29,67 @@ func (s *Storage) CreateOrder(ctx context.Context, userID int, order *domain.Ord
 	return nil
 }
 
+// CreateOrders inserts all numbers in one statement and returns the owners of the numbers
+// that already existed, keyed by number.
+func (s *Storage) CreateOrders(ctx context.Context, userID int, numbers []string) (map[string]int, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer s.rollback(ctx, tx)
+	rows, err := tx.Query(ctx, createOrdersSQL, userID, numbers, domain.New)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create orders in PG: %w", err)
+	}
+	inserted, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect created orders: %w", err)
+	}
+	owners := make(map[string]int, len(numbers)-len(inserted))
+	if len(inserted) < len(numbers) {
+		rows, err = tx.Query(ctx, getOrderOwnersSQL, conflictingNumbers(numbers, inserted))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get order owners in PG: %w", err)
+		}
+		for rows.Next() {
+			var (
+				number string
+				owner  int
+			)
+			if err = rows.Scan(&number, &owner); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan order owner: %w", err)
+			}
+			owners[number] = owner
+		}
+		if err = rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read order owners: %w", err)
+		}
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return owners, nil
+}
+
+func conflictingNumbers(numbers, inserted []string) []string {
+	created := make(map[string]struct{}, len(inserted))
+	for _, number := range inserted {
+		created[number] = struct{}{}
+	}
+	conflicts := make([]string, 0, len(numbers)-len(inserted))
+	for _, number := range numbers {
+		if _, ok := created[number]; !ok {
+			conflicts = append(conflicts, number)
+		}
+	}
+	return conflicts
+}
+
 func (s *Storage) UpdateOrder(ctx context.Context, order *domain.AccrualOut) error {
 	var err error
 	if order.Accrual != nil {
-		accrual := int64(*order.Accrual * accrualFactor)
+		accrual := toMinorUnits(*order.Accrual)
 		_, err = s.db.Exec(ctx, updateOrderWithAccrualSQL, order.Status, accrual, time.Now(), order.Order)
 	} else {
 		_, err = s.db.Exec(ctx, updateOrderSQL, order.Status, time.Now(), order.Order)
@@ -42,7 +106,7 @@ func (s *Storage) GetOrder(ctx context.Context, order *domain.OrderIn) (*domain.
 		accrual  sql.NullInt64
 	)
 	row := s.db.QueryRow(ctx, getOrderSQL, order.Number)
-	err := row.Scan(&orderOut.Number, &orderOut.Status, &orderOut.UserID, &accrual, &orderOut.UploadedAt)
+	err := row.Scan(&orderOut.ID, &orderOut.Number, &orderOut.Status, &orderOut.UserID, &accrual, &orderOut.UploadedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, errs.ErrNotFound
@@ -56,8 +120,13 @@ func (s *Storage) GetOrder(ctx context.Context, order *domain.OrderIn) (*domain.
 	return &orderOut, nil
 }
 
-func (s *Storage) GetAllOrders(ctx context.Context, userID int) (domain.OrderOutList, error) {
-	rows, err := s.db.Query(ctx, getAllOrdersByUserIDSQL, userID)
+func (s *Storage) GetAllOrders(ctx context.Context, query *domain.OrderQuery) (domain.OrderOutList, error) {
+	var builder pageBuilder
+	if len(query.Statuses) > 0 {
+		builder.where("status = ANY($%d)", query.Statuses)
+	}
+	listSQL, args := builder.build(listOrdersSQL, &query.PageQuery)
+	rows, err := s.db.Query(ctx, listSQL, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get orders in PG: %w", err)
 	}
@@ -68,6 +137,40 @@ func (s *Storage) GetAllOrders(ctx context.Context, userID int) (domain.OrderOut
 	return orders, nil
 }
 
+// StreamOrders passes every order matching query to fn without holding them all in memory.
+func (s *Storage) StreamOrders(
+	ctx context.Context,
+	query *domain.OrderQuery,
+	fn func(order *domain.OrderRecord) error,
+) error {
+	var builder pageBuilder
+	if len(query.Statuses) > 0 {
+		builder.where("status = ANY($%d)", query.Statuses)
+	}
+	listSQL, args := builder.build(listOrdersSQL, &query.PageQuery)
+	rows, err := s.db.Query(ctx, listSQL, args...)
+	if err != nil {
+		return fmt.Errorf("failed to get orders in PG: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			id, userID int
+			order      domain.OrderRecord
+		)
+		if err = rows.Scan(&id, &order.Number, &order.Status, &userID, &order.Accrual, &order.UploadedAt); err != nil {
+			return fmt.Errorf("failed to scan order: %w", err)
+		}
+		if err = fn(&order); err != nil {
+			return err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to read order rows: %w", err)
+	}
+	return nil
+}
+
 func (s *Storage) GetAllOrdersByStatus(ctx context.Context, status string) (domain.OrderOutList, error) {
 	rows, err := s.db.Query(ctx, getAllOrdersByStatusSQL, status)
 	if err != nil {
@@ -88,7 +191,7 @@ func (s *Storage) parseOrderRows(rows pgx.Rows) (domain.OrderOutList, error) {
 	for rows.Next() {
 		var order domain.OrderOut
 		var accrual sql.NullInt64
-		err := rows.Scan(&order.Number, &order.Status, &order.UserID, &accrual, &order.UploadedAt)
+		err := rows.Scan(&order.ID, &order.Number, &order.Status, &order.UserID, &accrual, &order.UploadedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse order in PG: %w", err)
 		}
@@ -0,0 +1,23 @@
+package postgres
+
+import "testing"
+
+func TestToMinorUnits(t *testing.T) {
+	tests := []struct {
+		sum  float32
+		want int64
+	}{
+		{sum: 0.29, want: 29},
+		{sum: 0.53, want: 53},
+		{sum: 1.05, want: 105},
+		{sum: 2.10, want: 210},
+		{sum: 4.18, want: 418},
+		{sum: -0.53, want: -53},
+		{sum: 0, want: 0},
+	}
+	for _, tt := range tests {
+		if got := toMinorUnits(tt.sum); got != tt.want {
+			t.Errorf("toMinorUnits(%v) = %d, want %d", tt.sum, got, tt.want)
+		}
+	}
+}
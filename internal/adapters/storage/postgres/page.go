@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"fmt"
+	"gophermart/internal/core/domain"
+	"strings"
+)
+
+// pageBuilder appends filter conditions to a base query and keeps placeholders numbered.
+type pageBuilder struct {
+	conditions []string
+	args       []any
+}
+
+// where adds a condition whose %d verbs are replaced with the positions of args.
+func (b *pageBuilder) where(condition string, args ...any) {
+	positions := make([]any, 0, len(args))
+	for _, arg := range args {
+		b.args = append(b.args, arg)
+		positions = append(positions, len(b.args))
+	}
+	b.conditions = append(b.conditions, fmt.Sprintf(condition, positions...))
+}
+
+// build renders base with the page filters, keyset condition, ordering and limit of query.
+// A zero limit selects every matching row.
+func (b *pageBuilder) build(base string, query *domain.PageQuery) (string, []any) {
+	b.where("user_id=$%d", query.UserID)
+	if query.From != nil {
+		b.where("created_at >= $%d", query.From.UTC())
+	}
+	if query.To != nil {
+		b.where("created_at < $%d", query.To.UTC())
+	}
+	direction, comparison := "ASC", ">"
+	if query.Sort == domain.SortDesc {
+		direction, comparison = "DESC", "<"
+	}
+	if query.After != nil {
+		b.where("(created_at, id) "+comparison+" ($%d, $%d)", query.After.CreatedAt, query.After.ID)
+	}
+	var sql strings.Builder
+	sql.WriteString(base)
+	for _, condition := range b.conditions {
+		sql.WriteString(" AND ")
+		sql.WriteString(condition)
+	}
+	fmt.Fprintf(&sql, " ORDER BY created_at %s, id %s", direction, direction)
+	if query.Limit > 0 {
+		b.args = append(b.args, query.Limit)
+		fmt.Fprintf(&sql, " LIMIT $%d", len(b.args))
+	}
+	return sql.String(), b.args
+}
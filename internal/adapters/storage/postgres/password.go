@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gophermart/internal/core/domain"
+	"gophermart/internal/errs"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *Storage) CreatePasswordResetToken(ctx context.Context, token *domain.PasswordResetToken) error {
+	_, err := s.db.Exec(ctx, createPasswordResetTokenSQL, token.UserID, token.TokenHash, token.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create password reset token in PG: %w", err)
+	}
+	return nil
+}
+
+// ResetPassword uses the reset token, sets the password and revokes the user's API keys in one transaction.
+func (s *Storage) ResetPassword(ctx context.Context, tokenHash, passwordHash string) error {
+	var userID int
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer s.rollback(ctx, tx)
+	now := time.Now()
+	if err = tx.QueryRow(ctx, usePasswordResetTokenSQL, now, tokenHash).Scan(&userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errs.ErrInvalidResetToken
+		}
+		return fmt.Errorf("failed to use password reset token in PG: %w", err)
+	}
+	if _, err = tx.Exec(ctx, updatePasswordSQL, passwordHash, userID); err != nil {
+		return fmt.Errorf("failed to update password in PG: %w", err)
+	}
+	if _, err = tx.Exec(ctx, expirePasswordResetTokensSQL, now, userID); err != nil {
+		return fmt.Errorf("failed to expire password reset tokens in PG: %w", err)
+	}
+	if _, err = tx.Exec(ctx, revokeAPIKeysSQL, now, userID); err != nil {
+		return fmt.Errorf("failed to revoke api keys in PG: %w", err)
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction %w", err)
+	}
+	return nil
+}
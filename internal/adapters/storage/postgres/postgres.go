@@ -2,11 +2,17 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"gophermart/internal/logger"
+	"gophermart/internal/metrics"
+	"sync/atomic"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	_ "github.com/jackc/pgx/v5/stdlib"
+	"go.uber.org/zap"
 )
 
 const (
@@ -14,14 +20,22 @@ const (
 )
 
 type Storage struct {
-	db *pgxpool.Pool
+	db                   *pgxpool.Pool
+	nextRateLimitPrune   atomic.Pointer[time.Time]
+	nextIdempotencyPrune atomic.Pointer[time.Time]
+	nextMFATokenPrune    atomic.Pointer[time.Time]
 }
 
 func NewPostgresStorage(cfg *Config) (*Storage, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(PGConnectionTimeout)*time.Second)
 	defer cancel()
 
-	pool, err := pgxpool.New(ctx, cfg.DSN)
+	poolConfig, err := pgxpool.ParseConfig(cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse postgres dsn %w", err)
+	}
+	poolConfig.ConnConfig.Tracer = queryTracer{}
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open postgres %w", err)
 	}
@@ -32,5 +46,14 @@ func NewPostgresStorage(cfg *Config) (*Storage, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to migrate %w", err)
 	}
+	if err = metrics.Registry.Register(metrics.NewPoolCollector(pool)); err != nil {
+		logger.Log.Error("failed to register pool metrics", zap.Error(err))
+	}
 	return &Storage{db: pool}, nil
 }
+
+func (s *Storage) rollback(ctx context.Context, tx pgx.Tx) {
+	if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+		logger.FromContext(ctx).Error("failed to rollback the transaction", zap.Error(err))
+	}
+}
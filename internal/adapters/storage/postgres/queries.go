@@ -1,26 +1,120 @@
 package postgres
 
 const (
-	getUserIDSQL              = `SELECT id FROM users WHERE login=$1 AND password_hash=$2`
+	getUserSQL                = `SELECT id, login, role, created_at FROM users WHERE login=$1 AND password_hash=$2`
 	createUserSQL             = `INSERT INTO users (login, password_hash) VALUES ($1, $2) RETURNING id`
 	createOrderSQL            = `INSERT INTO orders (user_id, number, status) VALUES ($1, $2, $3)`
 	updateOrderWithAccrualSQL = `UPDATE orders SET status=$1, accrual=$2, updated_at=$3 WHERE number=$4`
 	updateOrderSQL            = `UPDATE orders SET status=$1, updated_at=$2 WHERE number=$3`
-	getOrderSQL               = `SELECT number, status, user_id, accrual, updated_at FROM orders WHERE number=$1`
-	getAllOrdersByUserIDSQL   = `SELECT number, status, user_id, accrual, updated_at 
-					   		   FROM orders 
-					   		   WHERE user_id=$1 AND withdraw IS NULL ORDER BY updated_at`
-	getAllOrdersByStatusSQL = `SELECT number, status, user_id, accrual, updated_at 
+	getOrderSQL               = `SELECT id, number, status, user_id, accrual, created_at FROM orders WHERE number=$1`
+	listOrdersSQL             = `SELECT id, number, status, user_id, accrual, created_at
+							     FROM orders
+							     WHERE withdraw IS NULL`
+	getAllOrdersByStatusSQL = `SELECT id, number, status, user_id, accrual, updated_at 
 							   FROM orders 
 							   WHERE status=$1 AND withdraw IS NULL ORDER BY updated_at`
-	getBalanceSQL = `SELECT SUM(accrual) as total, SUM(withdraw) as withdraw 
+	createOrdersSQL = `INSERT INTO orders (user_id, number, status) 
+					   SELECT $1, number, $3 FROM unnest($2::varchar[]) AS number 
+					   ON CONFLICT (number) DO NOTHING 
+					   RETURNING number`
+	getOrderOwnersSQL = `SELECT number, user_id FROM orders WHERE number = ANY($1)`
+	getBalanceSQL     = `SELECT COALESCE(SUM(accrual), 0) + (
+                         SELECT COALESCE(SUM(amount), 0) FROM balance_adjustments WHERE user_id=$1
+                     ) as total, COALESCE(SUM(withdraw), 0) as withdraw 
                   	 FROM ( 
             			SELECT COALESCE(accrual, 0) as accrual, COALESCE(withdraw, 0) as withdraw 
             			FROM orders 
             			WHERE user_id=$1
             		 ) as t`
-	withdrawBonusesSQL   = `INSERT INTO orders (user_id, number, status, withdraw) VALUES ($1, $2, $3, $4)`
-	getAllWithdrawalsSQL = `SELECT number, withdraw, updated_at
-			   				FROM orders 
-			   				WHERE withdraw IS NOT NULL AND user_id=$1 ORDER BY updated_at`
+	withdrawBonusesSQL = `INSERT INTO orders (user_id, number, status, withdraw) VALUES ($1, $2, $3, $4)`
+	listWithdrawalsSQL = `SELECT id, number, withdraw, created_at
+			   			    FROM orders 
+			   			    WHERE withdraw IS NOT NULL`
+	lockUserSQL       = `SELECT id FROM users WHERE id=$1 FOR UPDATE`
+	getUserByIDSQL    = `SELECT id, login, role, created_at FROM users WHERE id=$1`
+	getUserByLoginSQL = `SELECT id, login, role, created_at FROM users WHERE login=$1`
+	requeueOrderSQL   = `UPDATE orders SET status=$1, updated_at=$2 
+					     WHERE number=$3 AND withdraw IS NULL AND status<>$4`
+	createBalanceAdjustmentSQL = `INSERT INTO balance_adjustments (user_id, admin_id, amount, reason) 
+								  VALUES ($1, $2, $3, $4)`
+	createAuditRecordSQL = `INSERT INTO audit_log (admin_id, action, target_user_id, details, session_id, auth_method) 
+							VALUES ($1, $2, $3, $4, $5, $6)`
+	createAPIKeySQL = `INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes) 
+					   VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at`
+	getAllAPIKeysSQL = `SELECT id, user_id, name, prefix, scopes, created_at, last_used_at 
+						FROM api_keys 
+						WHERE user_id=$1 AND revoked_at IS NULL ORDER BY created_at`
+	revokeAPIKeySQL  = `UPDATE api_keys SET revoked_at=$1 WHERE id=$2 AND user_id=$3 AND revoked_at IS NULL`
+	revokeAPIKeysSQL = `UPDATE api_keys SET revoked_at=$1 WHERE user_id=$2 AND revoked_at IS NULL`
+	useAPIKeySQL     = `UPDATE api_keys SET last_used_at=$1 
+					   WHERE key_hash=$2 AND revoked_at IS NULL 
+					   RETURNING id, user_id, name, prefix, scopes, created_at, last_used_at`
+	getTOTPSQL       = `SELECT COALESCE(totp_secret, ''), totp_enabled FROM users WHERE id=$1`
+	setTOTPSecretSQL = `UPDATE users SET totp_secret=$1, totp_enabled=FALSE WHERE id=$2 AND totp_enabled=FALSE`
+	enableTOTPSQL    = `UPDATE users SET totp_enabled=TRUE, totp_last_counter=$2 
+							  WHERE id=$1 AND totp_secret IS NOT NULL`
+	// useTOTPCounterSQL only moves forward, so a code of an accepted or earlier time step matches no row.
+	useTOTPCounterSQL = `UPDATE users SET totp_last_counter=$1 
+							  WHERE id=$2 AND totp_enabled AND totp_last_counter < $1`
+	disableTOTPSQL         = `UPDATE users SET totp_secret=NULL, totp_enabled=FALSE WHERE id=$1`
+	deleteRecoveryCodesSQL = `DELETE FROM totp_recovery_codes WHERE user_id=$1`
+	createRecoveryCodeSQL  = `INSERT INTO totp_recovery_codes (user_id, code_hash) VALUES ($1, $2)`
+	useRecoveryCodeSQL     = `UPDATE totp_recovery_codes SET used_at=$1 
+							  WHERE user_id=$2 AND code_hash=$3 AND used_at IS NULL`
+	useMFATokenSQL = `INSERT INTO used_mfa_tokens (token_id, expires_at) VALUES ($1, $2) 
+								   ON CONFLICT (token_id) DO NOTHING`
+	deleteMFATokensBeforeSQL    = `DELETE FROM used_mfa_tokens WHERE expires_at < $1`
+	createPasswordResetTokenSQL = `INSERT INTO password_reset_tokens (user_id, token_hash, expires_at) 
+								   VALUES ($1, $2, $3)`
+	usePasswordResetTokenSQL = `UPDATE password_reset_tokens SET used_at=$1 
+								WHERE token_hash=$2 AND used_at IS NULL AND expires_at > $1 
+								RETURNING user_id`
+	expirePasswordResetTokensSQL = `UPDATE password_reset_tokens SET used_at=$1 WHERE user_id=$2 AND used_at IS NULL`
+	updatePasswordSQL            = `UPDATE users SET password_hash=$1 WHERE id=$2`
+	getOrderEventsSinceSQL       = `SELECT id, user_id, number, status, accrual, created_at 
+							  FROM order_events 
+							  WHERE user_id=$1 AND id > $2 ORDER BY id LIMIT $3`
+	deleteOrderEventsBeforeSQL = `DELETE FROM order_events WHERE created_at < $1`
+	// statementEntriesSQL lists every balance movement of user $1 with credits positive and debits negative.
+	statementEntriesSQL = `SELECT 'accrual' AS kind, number AS reference, accrual AS amount, updated_at AS at, id 
+						   FROM orders WHERE user_id=$1 AND withdraw IS NULL AND accrual IS NOT NULL 
+						   UNION ALL 
+						   SELECT 'withdrawal', number, -withdraw, created_at, id 
+						   FROM orders WHERE user_id=$1 AND withdraw IS NOT NULL 
+						   UNION ALL 
+						   SELECT 'adjustment', reason, amount, created_at, id 
+						   FROM balance_adjustments WHERE user_id=$1`
+	getOpeningBalanceSQL = `SELECT COALESCE(SUM(amount), 0)::bigint FROM (` + statementEntriesSQL + `) e WHERE at < $2`
+	getStatementLinesSQL = `SELECT kind, reference, amount, at, 
+							($2::bigint + SUM(amount) OVER (ORDER BY at, kind, id))::bigint AS balance 
+							FROM (` + statementEntriesSQL + `) e 
+							WHERE ($3::timestamp IS NULL OR at >= $3) AND ($4::timestamp IS NULL OR at < $4) 
+							ORDER BY at, kind, id`
+	// takeRateLimitSQL counts a hit in the current window of key $1, starting over when $2 opens a new one.
+	takeRateLimitSQL = `INSERT INTO rate_limits (key, window_start, hits) VALUES ($1, $2, 1) 
+						ON CONFLICT (key) DO UPDATE SET 
+						hits = CASE WHEN rate_limits.window_start = EXCLUDED.window_start 
+							THEN rate_limits.hits + 1 ELSE 1 END, 
+						window_start = EXCLUDED.window_start 
+						RETURNING hits`
+	deleteRateLimitsBeforeSQL = `DELETE FROM rate_limits WHERE window_start < $1`
+	getMigrationVersionSQL    = `SELECT version_id FROM goose_db_version WHERE is_applied ORDER BY id DESC LIMIT 1`
+	// getOutstandingLiabilitySQL is the sum of every user's current balance.
+	getOutstandingLiabilitySQL = `SELECT (COALESCE(SUM(accrual), 0) - COALESCE(SUM(withdraw), 0) 
+								  + (SELECT COALESCE(SUM(amount), 0) FROM balance_adjustments))::bigint 
+								  FROM orders`
+	// reserveIdempotencyKeySQL claims key $2 of user $1, taking over a record that has expired.
+	reserveIdempotencyKeySQL = `INSERT INTO idempotency_keys (user_id, key, fingerprint, expires_at) 
+								VALUES ($1, $2, $3, $4) 
+								ON CONFLICT (user_id, key) DO UPDATE SET 
+								fingerprint = EXCLUDED.fingerprint, expires_at = EXCLUDED.expires_at, 
+								status = NULL, headers = NULL, body = NULL 
+								WHERE idempotency_keys.expires_at < $5 
+								RETURNING true`
+	getIdempotencyKeySQL = `SELECT fingerprint, status, headers, body FROM idempotency_keys 
+							WHERE user_id = $1 AND key = $2`
+	saveIdempotentResponseSQL = `UPDATE idempotency_keys SET status = $3, headers = $4, body = $5 
+								 WHERE user_id = $1 AND key = $2`
+	deleteIdempotencyKeySQL        = `DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2`
+	deleteIdempotencyKeysBeforeSQL = `DELETE FROM idempotency_keys WHERE expires_at < $1`
 )
@@ -0,0 +1,35 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"gophermart/internal/core/domain"
+	"gophermart/internal/logger"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TakeRateLimit counts a request against key in a fixed window and reports what is left of it.
+// Windows that ended long ago are pruned on the way, at most once per window.
+func (s *Storage) TakeRateLimit(
+	ctx context.Context,
+	key string,
+	limit int,
+	window time.Duration,
+) (*domain.RateLimitResult, error) {
+	now := time.Now().UTC()
+	start := now.Truncate(window)
+	var hits int
+	if err := s.db.QueryRow(ctx, takeRateLimitSQL, key, start).Scan(&hits); err != nil {
+		return nil, fmt.Errorf("failed to take rate limit in PG: %w", err)
+	}
+	if next := s.nextRateLimitPrune.Load(); next == nil || now.After(*next) {
+		pruneAt := now.Add(window)
+		s.nextRateLimitPrune.Store(&pruneAt)
+		if _, err := s.db.Exec(ctx, deleteRateLimitsBeforeSQL, start.Add(-window)); err != nil {
+			logger.FromContext(ctx).Error("failed to prune rate limits", zap.Error(err))
+		}
+	}
+	return domain.NewRateLimitResult(limit, hits, start.Add(window)), nil
+}
@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"gophermart/internal/core/domain"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *Storage) GetStatement(ctx context.Context, query *domain.StatementQuery) (*domain.Statement, error) {
+	statement := &domain.Statement{
+		From:  query.From,
+		To:    query.To,
+		Lines: make([]domain.StatementLine, 0),
+	}
+	err := s.StreamStatement(ctx, query, func(entry *domain.LedgerEntry) error {
+		balance := float32(entry.Balance) / accrualFactor
+		statement.ClosingBalance = balance
+		if entry.Type == domain.StatementOpening {
+			statement.OpeningBalance = balance
+			return nil
+		}
+		statement.Lines = append(statement.Lines, domain.StatementLine{
+			Type:        entry.Type,
+			Order:       entry.Order,
+			Description: entry.Description,
+			Amount:      float32(entry.Amount) / accrualFactor,
+			Balance:     balance,
+			Date:        entry.Date,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return statement, nil
+}
+
+// StreamStatement reads the opening balance and the lines of the range in one snapshot,
+// so the running balance always adds up. The first entry passed to fn is the opening balance.
+func (s *Storage) StreamStatement(
+	ctx context.Context,
+	query *domain.StatementQuery,
+	fn func(entry *domain.LedgerEntry) error,
+) error {
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer s.rollback(ctx, tx)
+	opening := domain.LedgerEntry{Type: domain.StatementOpening}
+	if query.From != nil {
+		opening.Date = query.From.UTC()
+		if err = tx.QueryRow(ctx, getOpeningBalanceSQL, query.UserID, opening.Date).Scan(&opening.Balance); err != nil {
+			return fmt.Errorf("failed to get opening balance in PG: %w", err)
+		}
+	}
+	if err = fn(&opening); err != nil {
+		return err
+	}
+	rows, err := tx.Query(
+		ctx,
+		getStatementLinesSQL,
+		query.UserID,
+		int64(opening.Balance),
+		utcOrNil(query.From),
+		utcOrNil(query.To),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get statement in PG: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			entry     domain.LedgerEntry
+			reference string
+		)
+		if err = rows.Scan(&entry.Type, &reference, &entry.Amount, &entry.Date, &entry.Balance); err != nil {
+			return fmt.Errorf("failed to scan statement line: %w", err)
+		}
+		if entry.Type == domain.StatementAdjustment {
+			entry.Description = reference
+		} else {
+			entry.Order = reference
+		}
+		if err = fn(&entry); err != nil {
+			return err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to read statement lines: %w", err)
+	}
+	return nil
+}
+
+func utcOrNil(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"gophermart/internal/logger"
+	"gophermart/internal/tracing"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const maxTracedStatement = 512
+
+// queryTracer opens a span per query and per pool acquire, so lock and pool waits show up in traces.
+// Failed queries are also logged at debug level with the caller's context logger.
+type queryTracer struct{}
+
+var (
+	_ pgx.QueryTracer       = queryTracer{}
+	_ pgxpool.AcquireTracer = queryTracer{}
+)
+
+func (queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	statement := strings.Join(strings.Fields(data.SQL), " ")
+	if len(statement) > maxTracedStatement {
+		statement = statement[:maxTracedStatement]
+	}
+	ctx, _ = tracing.Start(ctx, "pg "+operation(statement),
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.statement", statement),
+	)
+	return ctx
+}
+
+func (queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil {
+		tracing.Fail(span, data.Err)
+		if !errors.Is(data.Err, pgx.ErrNoRows) {
+			logger.FromContext(ctx).Debug("query failed", zap.Error(data.Err))
+		}
+	} else {
+		span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	}
+	span.End()
+}
+
+func (queryTracer) TraceAcquireStart(
+	ctx context.Context,
+	_ *pgxpool.Pool,
+	_ pgxpool.TraceAcquireStartData,
+) context.Context {
+	ctx, _ = tracing.Start(ctx, "pg acquire")
+	return ctx
+}
+
+func (queryTracer) TraceAcquireEnd(ctx context.Context, _ *pgxpool.Pool, data pgxpool.TraceAcquireEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil {
+		tracing.Fail(span, data.Err)
+	}
+	span.End()
+}
+
+// operation names the span after the statement's leading keyword, e.g. SELECT.
+func operation(statement string) string {
+	keyword, _, _ := strings.Cut(statement, " ")
+	return strings.ToUpper(keyword)
+}
@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gophermart/internal/core/domain"
+	"gophermart/internal/errs"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *Storage) GetTOTP(ctx context.Context, userID int) (*domain.TOTP, error) {
+	var totp domain.TOTP
+	if err := s.db.QueryRow(ctx, getTOTPSQL, userID).Scan(&totp.Secret, &totp.Enabled); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get totp in PG: %w", err)
+	}
+	return &totp, nil
+}
+
+func (s *Storage) SetTOTPSecret(ctx context.Context, userID int, secret string) error {
+	tag, err := s.db.Exec(ctx, setTOTPSecretSQL, secret, userID)
+	if err != nil {
+		return fmt.Errorf("failed to set totp secret in PG: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errs.ErrTwoFactorAlreadyEnabled
+	}
+	return nil
+}
+
+// EnableTOTP records counter, the time step of the confirming code, as used.
+func (s *Storage) EnableTOTP(ctx context.Context, userID int, counter int64, recoveryCodeHashes []string) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer s.rollback(ctx, tx)
+	tag, err := tx.Exec(ctx, enableTOTPSQL, userID, counter)
+	if err != nil {
+		return fmt.Errorf("failed to enable totp in PG: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errs.ErrTwoFactorNotEnabled
+	}
+	if _, err = tx.Exec(ctx, deleteRecoveryCodesSQL, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes in PG: %w", err)
+	}
+	for _, codeHash := range recoveryCodeHashes {
+		if _, err = tx.Exec(ctx, createRecoveryCodeSQL, userID, codeHash); err != nil {
+			return fmt.Errorf("failed to create recovery code in PG: %w", err)
+		}
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) DisableTOTP(ctx context.Context, userID int) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer s.rollback(ctx, tx)
+	if _, err = tx.Exec(ctx, disableTOTPSQL, userID); err != nil {
+		return fmt.Errorf("failed to disable totp in PG: %w", err)
+	}
+	if _, err = tx.Exec(ctx, deleteRecoveryCodesSQL, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes in PG: %w", err)
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction %w", err)
+	}
+	return nil
+}
+
+// UseTOTPCounter accepts a code of time step counter once. Codes of this or an earlier step return ErrNotFound.
+func (s *Storage) UseTOTPCounter(ctx context.Context, userID int, counter int64) error {
+	return useSecondFactor(ctx, s.db, userID, &domain.SecondFactor{TOTPCounter: counter})
+}
+
+func (s *Storage) UseRecoveryCode(ctx context.Context, userID int, codeHash string) error {
+	return useSecondFactor(ctx, s.db, userID, &domain.SecondFactor{RecoveryCodeHash: codeHash})
+}
+
+// useSecondFactor marks a TOTP time step or a recovery code as used, and returns ErrNotFound if it was used already.
+func useSecondFactor(ctx context.Context, db execer, userID int, factor *domain.SecondFactor) error {
+	if factor.RecoveryCodeHash != "" {
+		tag, err := db.Exec(ctx, useRecoveryCodeSQL, time.Now(), userID, factor.RecoveryCodeHash)
+		if err != nil {
+			return fmt.Errorf("failed to use recovery code in PG: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return errs.ErrNotFound
+		}
+		return nil
+	}
+	tag, err := db.Exec(ctx, useTOTPCounterSQL, factor.TOTPCounter, userID)
+	if err != nil {
+		return fmt.Errorf("failed to use totp counter in PG: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errs.ErrNotFound
+	}
+	return nil
+}
@@ -2,16 +2,13 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 	"gophermart/internal/core/domain"
 	"gophermart/internal/errs"
-	"gophermart/internal/logger"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
-	"go.uber.org/zap"
 )
 
 type Transaction interface {
@@ -22,10 +19,7 @@ func (s *Storage) getBalance(ctx context.Context, tx Transaction, userID int) (*
 	var balance domain.BalanceOut
 	row := tx.QueryRow(ctx, getBalanceSQL, userID)
 	if err := row.Scan(&balance.Current, &balance.Withdrawn); err != nil {
-		if !errors.Is(err, sql.ErrNoRows) {
-			return &domain.BalanceOut{Current: 0, Withdrawn: 0}, nil
-		}
-		return nil, fmt.Errorf("failed to get balance in PG: %w", err)
+		return nil, fmt.Errorf("failed to scan balance: %w", err)
 	}
 	balance.Current = float32(balance.Current-balance.Withdrawn) / accrualFactor
 	balance.Withdrawn = float32(balance.Withdrawn) / accrualFactor
@@ -40,12 +34,24 @@ func (s *Storage) GetBalance(ctx context.Context, userID int) (*domain.BalanceOu
 	return balance, nil
 }
 
-func (s *Storage) WithdrawBonuses(ctx context.Context, userID int, withdraw *domain.WithdrawalIn) error {
+// WithdrawBonuses checks the balance and withdraws under the user lock shared with balance adjustments.
+// A non-nil factor is used up in the same transaction and fails the withdrawal with ErrInvalidSecondFactor
+// if it was used already.
+func (s *Storage) WithdrawBonuses(
+	ctx context.Context,
+	userID int,
+	withdraw *domain.WithdrawalIn,
+	factor *domain.SecondFactor,
+) error {
 	var pgxErr *pgconn.PgError
 	tx, err := s.db.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to start transaction: %w", err)
 	}
+	defer s.rollback(ctx, tx)
+	if err = lockUser(ctx, tx, userID); err != nil {
+		return fmt.Errorf("failed to lock user for withdraw bonuses: %w", err)
+	}
 	balance, err := s.getBalance(ctx, tx, userID)
 	if err != nil {
 		return fmt.Errorf("failed to get balance for withdraw bonuses: %w", err)
@@ -53,24 +59,28 @@ func (s *Storage) WithdrawBonuses(ctx context.Context, userID int, withdraw *dom
 	if balance.Current < withdraw.Sum {
 		return errs.ErrNotEnoughFunds
 	}
+	if factor != nil {
+		err = useSecondFactor(ctx, tx, userID, factor)
+		if errors.Is(err, errs.ErrNotFound) {
+			return errs.ErrInvalidSecondFactor
+		}
+		if err != nil {
+			return fmt.Errorf("failed to use second factor for withdraw bonuses: %w", err)
+		}
+	}
 	_, err = tx.Exec(
 		ctx,
 		withdrawBonusesSQL,
 		userID,
 		withdraw.OrderNumber,
 		domain.Processed,
-		int64(withdraw.Sum*accrualFactor),
+		toMinorUnits(withdraw.Sum),
 	)
 	if err != nil {
 		ok := errors.As(err, &pgxErr)
 		if ok && pgxErr.Code == PGUniqueViolationCode {
 			return errs.ErrWithdrawAlreadyExist
 		}
-		if txErr := tx.Rollback(ctx); txErr != nil {
-			if !errors.Is(txErr, sql.ErrTxDone) {
-				logger.Log.Error("failed to rollback the transaction", zap.Error(txErr))
-			}
-		}
 		return fmt.Errorf("failed to withdraw bonuses: %w", err)
 	}
 	if err = tx.Commit(ctx); err != nil {
@@ -79,9 +89,14 @@ func (s *Storage) WithdrawBonuses(ctx context.Context, userID int, withdraw *dom
 	return nil
 }
 
-func (s *Storage) GetAllWithdrawals(ctx context.Context, userID int) (domain.WithdrawOutList, error) {
+func (s *Storage) GetAllWithdrawals(
+	ctx context.Context,
+	query *domain.WithdrawalQuery,
+) (domain.WithdrawOutList, error) {
 	withdrawals := make(domain.WithdrawOutList, 0)
-	rows, err := s.db.Query(ctx, getAllWithdrawalsSQL, userID)
+	var builder pageBuilder
+	listSQL, args := builder.build(listWithdrawalsSQL, &query.PageQuery)
+	rows, err := s.db.Query(ctx, listSQL, args...)
 	defer func() {
 		rows.Close()
 	}()
@@ -90,7 +105,7 @@ func (s *Storage) GetAllWithdrawals(ctx context.Context, userID int) (domain.Wit
 	}
 	for rows.Next() {
 		var withdraw domain.WithdrawalsOut
-		err = rows.Scan(&withdraw.Order, &withdraw.Sum, &withdraw.ProcessedAt)
+		err = rows.Scan(&withdraw.ID, &withdraw.Order, &withdraw.Sum, &withdraw.ProcessedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get all withdrawals%w", err)
 		}
@@ -103,3 +118,34 @@ func (s *Storage) GetAllWithdrawals(ctx context.Context, userID int) (domain.Wit
 	}
 	return withdrawals, nil
 }
+
+// StreamWithdrawals passes every withdrawal matching query to fn without holding them all in memory.
+func (s *Storage) StreamWithdrawals(
+	ctx context.Context,
+	query *domain.WithdrawalQuery,
+	fn func(withdrawal *domain.WithdrawalRecord) error,
+) error {
+	var builder pageBuilder
+	listSQL, args := builder.build(listWithdrawalsSQL, &query.PageQuery)
+	rows, err := s.db.Query(ctx, listSQL, args...)
+	if err != nil {
+		return fmt.Errorf("failed to get withdrawals in PG: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			id         int
+			withdrawal domain.WithdrawalRecord
+		)
+		if err = rows.Scan(&id, &withdrawal.Order, &withdrawal.Sum, &withdrawal.ProcessedAt); err != nil {
+			return fmt.Errorf("failed to scan withdrawal: %w", err)
+		}
+		if err = fn(&withdrawal); err != nil {
+			return err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to read withdrawal rows: %w", err)
+	}
+	return nil
+}
@@ -7,31 +7,120 @@ import (
 	"gophermart/internal/adapters/storage/postgres"
 	"gophermart/internal/config"
 	"gophermart/internal/core/domain"
+	"time"
 )
 
 type Authorization interface {
 	CreateUser(ctx context.Context, user *domain.UserIn) error
-	GetUserID(ctx context.Context, user *domain.UserIn) (int, error)
+	GetUser(ctx context.Context, user *domain.UserIn) (*domain.User, error)
+	UseMFAToken(ctx context.Context, tokenID string, expiresAt time.Time) error
 }
 
 type Order interface {
 	CreateOrder(ctx context.Context, userID int, order *domain.OrderIn) error
+	CreateOrders(ctx context.Context, userID int, numbers []string) (map[string]int, error)
 	UpdateOrder(ctx context.Context, order *domain.AccrualOut) error
 	GetOrder(ctx context.Context, order *domain.OrderIn) (*domain.OrderOut, error)
-	GetAllOrders(ctx context.Context, userID int) (domain.OrderOutList, error)
+	GetAllOrders(ctx context.Context, query *domain.OrderQuery) (domain.OrderOutList, error)
 	GetAllOrdersByStatus(ctx context.Context, status string) (domain.OrderOutList, error)
+	StreamOrders(ctx context.Context, query *domain.OrderQuery, fn func(order *domain.OrderRecord) error) error
 }
 
 type Withdrawal interface {
 	GetBalance(ctx context.Context, userID int) (*domain.BalanceOut, error)
-	WithdrawBonuses(ctx context.Context, userID int, withdraw *domain.WithdrawalIn) error
-	GetAllWithdrawals(ctx context.Context, userID int) (domain.WithdrawOutList, error)
+	WithdrawBonuses(ctx context.Context, userID int, withdraw *domain.WithdrawalIn, factor *domain.SecondFactor) error
+	GetAllWithdrawals(ctx context.Context, query *domain.WithdrawalQuery) (domain.WithdrawOutList, error)
+	GetStatement(ctx context.Context, query *domain.StatementQuery) (*domain.Statement, error)
+	StreamStatement(ctx context.Context, query *domain.StatementQuery, fn func(entry *domain.LedgerEntry) error) error
+	StreamWithdrawals(
+		ctx context.Context,
+		query *domain.WithdrawalQuery,
+		fn func(withdrawal *domain.WithdrawalRecord) error,
+	) error
+}
+
+type Admin interface {
+	GetUserByID(ctx context.Context, userID int) (*domain.UserOut, error)
+	GetUserByLogin(ctx context.Context, login string) (*domain.UserOut, error)
+	GetAllOrders(ctx context.Context, query *domain.OrderQuery) (domain.OrderOutList, error)
+	GetAllWithdrawals(ctx context.Context, query *domain.WithdrawalQuery) (domain.WithdrawOutList, error)
+	GetBalance(ctx context.Context, userID int) (*domain.BalanceOut, error)
+	RequeueOrder(ctx context.Context, number string, audit *domain.AuditRecord) error
+	AdjustBalance(
+		ctx context.Context,
+		userID int,
+		adjustment *domain.BalanceAdjustmentIn,
+		audit *domain.AuditRecord,
+	) error
+	CreateAuditRecord(ctx context.Context, record *domain.AuditRecord) error
+}
+
+type APIKey interface {
+	CreateAPIKey(ctx context.Context, key *domain.APIKey) error
+	GetAllAPIKeys(ctx context.Context, userID int) (domain.APIKeyList, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID int) error
+	UseAPIKey(ctx context.Context, keyHash string) (*domain.APIKey, error)
+}
+
+type TwoFactor interface {
+	GetUserByID(ctx context.Context, userID int) (*domain.UserOut, error)
+	GetTOTP(ctx context.Context, userID int) (*domain.TOTP, error)
+	SetTOTPSecret(ctx context.Context, userID int, secret string) error
+	EnableTOTP(ctx context.Context, userID int, counter int64, recoveryCodeHashes []string) error
+	DisableTOTP(ctx context.Context, userID int) error
+	UseTOTPCounter(ctx context.Context, userID int, counter int64) error
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) error
+}
+
+type Password interface {
+	GetUserByLogin(ctx context.Context, login string) (*domain.UserOut, error)
+	CreatePasswordResetToken(ctx context.Context, token *domain.PasswordResetToken) error
+	ResetPassword(ctx context.Context, tokenHash, passwordHash string) error
+}
+
+type OrderEvents interface {
+	ListenOrderEvents(ctx context.Context, events chan<- domain.OrderEvent, listening func()) error
+	GetOrderEventsSince(ctx context.Context, userID int, afterID int64, limit int) ([]domain.OrderEvent, error)
+	DeleteOrderEventsBefore(ctx context.Context, before time.Time) error
+}
+
+type RateLimit interface {
+	TakeRateLimit(ctx context.Context, key string, limit int, window time.Duration) (*domain.RateLimitResult, error)
+}
+
+type Idempotency interface {
+	ReserveIdempotencyKey(
+		ctx context.Context,
+		userID int,
+		key, fingerprint string,
+		ttl time.Duration,
+	) (*domain.IdempotencyRecord, bool, error)
+	SaveIdempotentResponse(ctx context.Context, userID int, key string, response *domain.IdempotentResponse) error
+	DeleteIdempotencyKey(ctx context.Context, userID int, key string) error
+}
+
+type Health interface {
+	Ping(ctx context.Context) error
+	MigrationVersion(ctx context.Context) (current, latest int64, err error)
+}
+
+type Metrics interface {
+	GetOutstandingLiability(ctx context.Context) (float64, error)
 }
 
 type Storage interface {
 	Authorization
 	Order
 	Withdrawal
+	Admin
+	APIKey
+	TwoFactor
+	Password
+	OrderEvents
+	RateLimit
+	Idempotency
+	Health
+	Metrics
 }
 
 func NewStorage(cfg *config.Config) (Storage, error) {
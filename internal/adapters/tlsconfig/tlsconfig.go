@@ -0,0 +1,205 @@
+// Package tlsconfig builds the server TLS configuration and keeps the certificates fresh,
+// reloading them when the files change or on SIGHUP without dropping open connections.
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"gophermart/internal/config"
+	"gophermart/internal/core/domain"
+	"gophermart/internal/errs"
+	"gophermart/internal/logger"
+	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var errNoClientCA = errors.New("no certificates found in client ca file")
+
+var versions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Reloader hands out the current TLS configuration to every new handshake.
+type Reloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+	minVersion   uint16
+	cipherSuites []uint16
+	interval     time.Duration
+	current      atomic.Pointer[tls.Config]
+	modTimes     []time.Time
+}
+
+func New(cfg *config.Config) (*Reloader, error) {
+	minVersion, ok := versions[cfg.TLSMinVersion]
+	if !ok {
+		return nil, fmt.Errorf("unsupported tls min version %q, use 1.2 or 1.3", cfg.TLSMinVersion)
+	}
+	cipherSuites, err := parseCipherSuites(cfg.TLSCipherSuites)
+	if err != nil {
+		return nil, err
+	}
+	r := &Reloader{
+		certFile:     cfg.TLSCertFile,
+		keyFile:      cfg.TLSKeyFile,
+		clientCAFile: cfg.TLSClientCAFile,
+		minVersion:   minVersion,
+		cipherSuites: cipherSuites,
+		interval:     time.Duration(cfg.TLSReloadInterval) * time.Second,
+	}
+	if err = r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// TLSConfig returns the config for the REST and gRPC servers; each handshake picks up the latest certificates.
+func (r *Reloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: r.minVersion,
+		NextProtos: []string{"h2", "http/1.1"},
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return &r.current.Load().Certificates[0], nil
+		},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.current.Load(), nil
+		},
+	}
+}
+
+// CheckPartnerCert makes API key callers, i.e. partner integrations, present a client certificate verified
+// against the partner CA during the handshake. It is a no-op without TLS or a client CA.
+func (r *Reloader) CheckPartnerCert(principal *domain.Principal, state *tls.ConnectionState) error {
+	if r == nil || r.clientCAFile == "" || principal.AuthMethod != domain.AuthMethodAPIKey {
+		return nil
+	}
+	if state == nil || len(state.VerifiedChains) == 0 {
+		return fmt.Errorf("%w: client certificate required", errs.ErrForbidden)
+	}
+	return nil
+}
+
+// Watch reloads the certificates on SIGHUP and whenever one of the files changes until ctx is done.
+// A failed reload is logged and the previous certificates stay in use.
+func (r *Reloader) Watch(ctx context.Context) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			r.reloadAndLog("sighup")
+		case <-ticker.C:
+			if r.changed() {
+				r.reloadAndLog("file change")
+			}
+		}
+	}
+}
+
+func (r *Reloader) reloadAndLog(reason string) {
+	if err := r.reload(); err != nil {
+		logger.Log.Error("failed to reload tls certificates", zap.String("reason", reason), zap.Error(err))
+		return
+	}
+	logger.Log.Info("tls certificates reloaded", zap.String("reason", reason))
+}
+
+func (r *Reloader) reload() error {
+	modTimes, err := r.stat()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load tls key pair: %w", err)
+	}
+	next := &tls.Config{
+		MinVersion:   r.minVersion,
+		CipherSuites: r.cipherSuites,
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	if r.clientCAFile != "" {
+		pem, readErr := os.ReadFile(r.clientCAFile)
+		if readErr != nil {
+			return fmt.Errorf("failed to read client ca file: %w", readErr)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return errNoClientCA
+		}
+		// Only partner routes insist on a certificate, everyone else may connect without one.
+		next.ClientCAs = pool
+		next.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	r.current.Store(next)
+	r.modTimes = modTimes
+	return nil
+}
+
+func (r *Reloader) changed() bool {
+	modTimes, err := r.stat()
+	if err != nil {
+		// Files are often swapped non-atomically, the next tick will see the complete set.
+		return false
+	}
+	for i := range modTimes {
+		if !modTimes[i].Equal(r.modTimes[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reloader) stat() ([]time.Time, error) {
+	files := []string{r.certFile, r.keyFile}
+	if r.clientCAFile != "" {
+		files = append(files, r.clientCAFile)
+	}
+	modTimes := make([]time.Time, 0, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat tls file: %w", err)
+		}
+		modTimes = append(modTimes, info.ModTime())
+	}
+	return modTimes, nil
+}
+
+// parseCipherSuites maps a comma separated list of IANA names onto Go's secure TLS 1.2 suites.
+// An empty list keeps Go's defaults; TLS 1.3 suites are not configurable.
+func parseCipherSuites(names string) ([]uint16, error) {
+	if names == "" {
+		return nil, nil
+	}
+	secure := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		secure[suite.Name] = suite.ID
+	}
+	var ids []uint16
+	for _, name := range strings.Split(names, ",") {
+		id, ok := secure[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown or insecure tls cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
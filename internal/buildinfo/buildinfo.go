@@ -0,0 +1,48 @@
+// Package buildinfo describes the running binary.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// Version is set at build time with -ldflags "-X gophermart/internal/buildinfo.Version=...".
+var Version = "dev"
+
+var started = time.Now()
+
+type Info struct {
+	Version   string    `json:"version"`
+	Revision  string    `json:"revision,omitempty"`
+	Modified  bool      `json:"modified"`
+	BuildTime string    `json:"build_time,omitempty"`
+	GoVersion string    `json:"go_version"`
+	Module    string    `json:"module,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// Read collects the version together with the vcs details the go toolchain stamped into the binary.
+func Read() *Info {
+	info := &Info{
+		Version:   Version,
+		GoVersion: runtime.Version(),
+		StartedAt: started,
+	}
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.Module = build.Main.Path
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Revision = setting.Value
+		case "vcs.time":
+			info.BuildTime = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
@@ -7,11 +7,14 @@ import (
 	"gophermart/internal/config"
 	"gophermart/internal/core/domain"
 	"gophermart/internal/logger"
+	"gophermart/internal/metrics"
+	"gophermart/internal/tracing"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/go-resty/resty/v2"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 )
@@ -23,6 +26,7 @@ type Service struct {
 	config        *config.Config
 	client        *resty.Client
 	workerTimeout *WorkerTimeoutMap
+	circuit       *Circuit
 }
 
 func NewAccrualService(
@@ -36,61 +40,93 @@ func NewAccrualService(
 		client:        client,
 		config:        cfg,
 		workerTimeout: workerTimeoutMap,
+		circuit: NewCircuit(
+			cfg.CircuitThreshold,
+			time.Duration(cfg.CircuitCooldown)*time.Second,
+		),
 	}
 }
 
+// CircuitState reports whether the workers currently talk to the accrual system.
+func (s *Service) CircuitState() string {
+	return s.circuit.State()
+}
+
+// Ping checks that the accrual system answers HTTP at all, whatever the status.
+func (s *Service) Ping(ctx context.Context) error {
+	if _, err := s.client.R().SetContext(ctx).Head(s.config.AccrualSystemAddress); err != nil {
+		return fmt.Errorf("accrual system is unreachable: %w", err)
+	}
+	return nil
+}
+
+// getOrders queues the unfinished orders every poll interval until ctx is done. It never blocks past ctx,
+// even when the workers have stopped taking orders.
 func (s *Service) getOrders(ctx context.Context, orders chan<- string) error {
 	accrualPollTicker := time.NewTicker(time.Duration(s.config.AccrualPollInterval) * time.Second)
 	defer accrualPollTicker.Stop()
-	for range accrualPollTicker.C {
-		processingOrders, err := s.storage.GetAllOrdersByStatus(ctx, domain.Processing)
-		if err != nil {
-			logger.Log.Error("error occurred during collecting processing orders", zap.Error(err))
-			return fmt.Errorf("error occurred during collecting processing orders: %w", err)
-		}
-		registeredOrders, err := s.storage.GetAllOrdersByStatus(ctx, domain.Registered)
-		if err != nil {
-			logger.Log.Error("error occurred during collecting registered orders", zap.Error(err))
-			return fmt.Errorf("error occurred during collecting registered orders: %w", err)
-		}
-		newOrders, err := s.storage.GetAllOrdersByStatus(ctx, domain.New)
-		if err != nil {
-			logger.Log.Error("error occurred during collecting new orders", zap.Error(err))
-			return fmt.Errorf("error occurred during collecting new orders: %w", err)
-		}
-		for _, order := range processingOrders {
-			orders <- order.Number
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-accrualPollTicker.C:
 		}
-		for _, order := range registeredOrders {
-			orders <- order.Number
+		for _, status := range []string{domain.Processing, domain.Registered, domain.New} {
+			list, err := s.storage.GetAllOrdersByStatus(ctx, status)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				logger.Log.Error("error occurred during collecting orders", zap.String("status", status), zap.Error(err))
+				return fmt.Errorf("error occurred during collecting %s orders: %w", status, err)
+			}
+			if !enqueue(ctx, orders, list) {
+				return nil
+			}
 		}
-		for _, order := range newOrders {
-			orders <- order.Number
+	}
+}
+
+// enqueue hands the orders to the workers and returns false once ctx is done.
+func enqueue(ctx context.Context, orders chan<- string, list domain.OrderOutList) bool {
+	for _, order := range list {
+		select {
+		case orders <- order.Number:
+			metrics.AccrualQueued.Inc()
+		case <-ctx.Done():
+			return false
 		}
 	}
-	return nil
+	return true
 }
 
-func (s *Service) getOrderStatus(orderNumber string) (*domain.AccrualOut, error) {
+func (s *Service) getOrderStatus(ctx context.Context, orderNumber, correlationID string) (*domain.AccrualOut, error) {
 	var order domain.AccrualOut
+	start := time.Now()
 	resp, err := s.client.R().
+		SetContext(ctx).
+		SetHeader("X-Request-ID", correlationID).
 		SetResult(&order).
 		Get(fmt.Sprintf("%s/api/orders/%s", s.config.AccrualSystemAddress, orderNumber))
 
+	s.circuit.Record(err != nil || resp.StatusCode() >= http.StatusInternalServerError)
 	if err != nil {
+		metrics.AccrualRequestDuration.WithLabelValues("error").Observe(time.Since(start).Seconds())
 		return nil, fmt.Errorf("%w", err)
 	}
+	metrics.AccrualRequestDuration.WithLabelValues(strconv.Itoa(resp.StatusCode())).Observe(time.Since(start).Seconds())
 
 	switch resp.StatusCode() {
 	case http.StatusOK:
 		return &order, nil
 	case http.StatusTooManyRequests:
+		metrics.AccrualRateLimited.Inc()
 		retryAfterHeader := resp.Header().Get("Retry-After")
 		if retryAfterHeader != "" {
 			timeout, err := strconv.Atoi(retryAfterHeader)
 			if err != nil {
 				s.workerTimeout.Broadcast(s.config.AccrualTimeout)
-				logger.Log.Error("too many request to accrual service", zap.Error(err))
+				logger.FromContext(ctx).Error("too many request to accrual service", zap.Error(err))
 			}
 			s.workerTimeout.Broadcast(timeout)
 		}
@@ -107,18 +143,44 @@ func (s *Service) updateOrderStatus(ctx context.Context, order *domain.AccrualOu
 	return nil
 }
 
-func (s *Service) processOrder(ctx context.Context, orderNumber string) error {
-	order, err := s.getOrderStatus(orderNumber)
+// processOrder gives every attempt its own correlation id, which is logged, traced and sent to the accrual system.
+// Failures are logged and the order is polled again on the next tick, repeated ones open the circuit.
+func (s *Service) processOrder(ctx context.Context, orderNumber string) {
+	correlationID := logger.NewCorrelationID()
+	ctx = logger.With(ctx, zap.String("correlation_id", correlationID), zap.String("order", orderNumber))
+	ctx, span := tracing.Start(ctx, "accrual.processOrder",
+		attribute.String("order.number", orderNumber),
+		attribute.String("correlation.id", correlationID),
+	)
+	defer span.End()
+	order, err := s.getOrderStatus(ctx, orderNumber, correlationID)
 	if err != nil {
-		logger.Log.Error("error during processing order", zap.Error(err))
-		return fmt.Errorf("error occurred during getting order statu: %w", err)
+		tracing.Fail(span, err)
+		metrics.AccrualFailed.Inc()
+		logger.FromContext(ctx).Error("error during processing order", zap.Error(err))
+		return
 	}
 	err = s.updateOrderStatus(ctx, order)
 	if err != nil {
-		logger.Log.Error("error during updating order status", zap.Error(err))
-		return fmt.Errorf("error occurred during updating order status: %w", err)
+		tracing.Fail(span, err)
+		metrics.AccrualFailed.Inc()
+		logger.FromContext(ctx).Error("error during updating order status", zap.Error(err))
+		return
 	}
-	return nil
+	metrics.AccrualProcessed.WithLabelValues(order.Status).Inc()
+	if order.Status == domain.Processed {
+		s.observeProcessingDelay(ctx, orderNumber)
+	}
+}
+
+// observeProcessingDelay records how long the order took from upload to PROCESSED.
+func (s *Service) observeProcessingDelay(ctx context.Context, orderNumber string) {
+	uploaded, err := s.storage.GetOrder(ctx, &domain.OrderIn{Number: orderNumber})
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to get order upload time", zap.Error(err))
+		return
+	}
+	metrics.AccrualProcessingDelay.Observe(time.Since(uploaded.UploadedAt).Seconds())
 }
 
 func (s *Service) Run(ctx context.Context) error {
@@ -133,10 +195,7 @@ func (s *Service) Run(ctx context.Context) error {
 	})
 	for w := 1; w <= s.config.AccrualRateLimit; w++ {
 		g.Go(func() error {
-			err := s.worker(ctx, orders, w)
-			if err != nil {
-				return fmt.Errorf("error occurred during worker: %w", err)
-			}
+			s.worker(ctx, orders, w)
 			return nil
 		})
 	}
@@ -147,20 +206,23 @@ func (s *Service) Run(ctx context.Context) error {
 	return nil
 }
 
-func (s *Service) worker(ctx context.Context, orders <-chan string, id int) error {
+// worker processes orders until ctx is done. An accrual outage opens the circuit rather than stopping it.
+func (s *Service) worker(ctx context.Context, orders <-chan string, id int) {
+	ctx = logger.With(ctx, zap.Int("worker", id))
 	for {
 		select {
 		case orderNumber, ok := <-orders:
 			if !ok {
-				return nil
+				return
 			}
-			if err := s.processOrder(ctx, orderNumber); err != nil {
-				return fmt.Errorf("error occurred during processing order: %w", err)
+			if s.circuit.Wait(ctx) != nil {
+				return
 			}
+			s.processOrder(ctx, orderNumber)
 		case timeout := <-s.workerTimeout.GetWorker(id):
 			time.Sleep(time.Duration(timeout) * time.Second)
 		case <-ctx.Done():
-			return nil
+			return
 		}
 	}
 }
@@ -0,0 +1,99 @@
+package accrual
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+// Circuit stops the workers from hammering an accrual system that keeps failing.
+// After threshold consecutive failures it opens for cooldown, then lets a single trial request through
+// and holds every other caller until the outcome of that trial is recorded.
+type Circuit struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+	probing   atomic.Bool
+	resolved  chan struct{}
+}
+
+func NewCircuit(threshold int, cooldown time.Duration) *Circuit {
+	return &Circuit{threshold: threshold, cooldown: cooldown}
+}
+
+func (c *Circuit) State() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state()
+}
+
+func (c *Circuit) state() string {
+	switch {
+	case c.threshold <= 0 || c.failures < c.threshold:
+		return CircuitClosed
+	case time.Since(c.openedAt) < c.cooldown:
+		return CircuitOpen
+	default:
+		return CircuitHalfOpen
+	}
+}
+
+// Wait blocks while the circuit is open, and while half-open unless the caller takes the trial request.
+func (c *Circuit) Wait(ctx context.Context) error {
+	for {
+		c.mu.Lock()
+		state, remaining, resolved := c.state(), c.cooldown-time.Since(c.openedAt), c.resolved
+		if state == CircuitHalfOpen && c.probing.CompareAndSwap(false, true) {
+			c.resolved = make(chan struct{})
+			c.mu.Unlock()
+			return nil
+		}
+		c.mu.Unlock()
+		switch state {
+		case CircuitClosed:
+			return nil
+		case CircuitOpen:
+			timer := time.NewTimer(remaining)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return fmt.Errorf("circuit wait: %w", ctx.Err())
+			}
+		default:
+			select {
+			case <-resolved:
+			case <-ctx.Done():
+				return fmt.Errorf("circuit wait: %w", ctx.Err())
+			}
+		}
+	}
+}
+
+// Record counts the outcome of a request and releases the callers held behind a trial request.
+// A failed trial request opens the circuit again.
+func (c *Circuit) Record(failed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.probing.CompareAndSwap(true, false) {
+		close(c.resolved)
+	}
+	if !failed {
+		c.failures = 0
+		return
+	}
+	c.failures++
+	if c.failures >= c.threshold {
+		c.openedAt = time.Now()
+	}
+}
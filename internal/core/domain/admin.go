@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+const (
+	AuditFindUserByLogin    = "find_user_by_login"
+	AuditGetUser            = "get_user"
+	AuditGetUserOrders      = "get_user_orders"
+	AuditGetUserWithdrawals = "get_user_withdrawals"
+	AuditGetUserBalance     = "get_user_balance"
+	AuditRequeueOrder       = "requeue_order"
+	AuditAdjustBalance      = "adjust_balance"
+)
+
+type BalanceAdjustmentIn struct {
+	Sum    float32 `json:"sum"`
+	Reason string  `json:"reason"`
+}
+
+type AuditRecord struct {
+	AdminID      int
+	Action       string
+	TargetUserID *int
+	Details      string
+	SessionID    string
+	AuthMethod   string
+	CreatedAt    time.Time
+}
@@ -1,9 +1,13 @@
 package domain
 
+import "time"
+
 type User struct {
-	ID       int
-	Login    string
-	Password string
+	ID        int
+	Login     string
+	Password  string
+	Role      string
+	CreatedAt time.Time
 }
 
 type UserIn struct {
@@ -11,3 +15,10 @@ type UserIn struct {
 	Password     string `json:"password"`
 	PasswordHash string `json:"-"`
 }
+
+type UserOut struct {
+	ID        int       `json:"id"`
+	Login     string    `json:"login"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// OrderEvent is a change of status or accrual of an order, numbered for stream resume.
+type OrderEvent struct {
+	ID        int64     `json:"-"`
+	UserID    int       `json:"-"`
+	Number    string    `json:"number"`
+	Status    string    `json:"status"`
+	Accrual   *float32  `json:"accrual,omitempty"`
+	CreatedAt time.Time `json:"changed_at"`
+}
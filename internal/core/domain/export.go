@@ -0,0 +1,35 @@
+package domain
+
+import "time"
+
+const (
+	ExportCSV = "csv"
+	ExportPDF = "pdf"
+
+	// StatementOpening and StatementClosing mark the balance rows framing an exported statement.
+	StatementOpening = "opening"
+	StatementClosing = "closing"
+)
+
+// LedgerEntry is a statement line with exact amounts, used by exports.
+type LedgerEntry struct {
+	Type        string
+	Order       string
+	Description string
+	Amount      Money
+	Balance     Money
+	Date        time.Time
+}
+
+type OrderRecord struct {
+	Number     string
+	Status     string
+	Accrual    *Money
+	UploadedAt time.Time
+}
+
+type WithdrawalRecord struct {
+	Order       string
+	Sum         Money
+	ProcessedAt time.Time
+}
@@ -0,0 +1,14 @@
+package domain
+
+// IdempotentResponse is the first response to a request sent with an Idempotency-Key.
+type IdempotentResponse struct {
+	Status int
+	Header map[string][]string
+	Body   []byte
+}
+
+// IdempotencyRecord is what a key was first used for. Response stays nil while that request is in flight.
+type IdempotencyRecord struct {
+	Fingerprint string
+	Response    *IdempotentResponse
+}
@@ -0,0 +1,17 @@
+package domain
+
+import "fmt"
+
+const minorUnits = 100
+
+// Money is an amount in minor currency units. It is formatted from integers,
+// so exported amounts never carry float rounding artefacts.
+type Money int64
+
+func (m Money) String() string {
+	sign, value := "", int64(m)
+	if value < 0 {
+		sign, value = "-", -value
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, value/minorUnits, value%minorUnits)
+}
@@ -7,9 +7,11 @@ const (
 	Processing = "PROCESSING"
 	New        = "NEW"
 	Registered = "REGISTERED"
+	Invalid    = "INVALID"
 )
 
 type OrderOut struct {
+	ID         int       `json:"-"`
 	Number     string    `json:"number"`
 	Status     string    `json:"status"`
 	Accrual    *float32  `json:"accrual,omitempty"`
@@ -22,3 +24,20 @@ type OrderIn struct {
 }
 
 type OrderOutList []OrderOut
+
+const (
+	MaxBulkOrders = 1000
+
+	BulkAccepted           = "accepted"
+	BulkAlreadyUploaded    = "already_uploaded"
+	BulkOwnedByAnotherUser = "owned_by_another_user"
+	BulkInvalidNumber      = "invalid_number"
+)
+
+// BulkOrderResult reports what happened to one number of a bulk upload.
+type BulkOrderResult struct {
+	Number string `json:"number"`
+	Result string `json:"result"`
+}
+
+type BulkOrderResults []BulkOrderResult
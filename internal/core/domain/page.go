@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SortAsc          = "asc"
+	SortDesc         = "desc"
+	DefaultPageLimit = 100
+	MaxPageLimit     = 1000
+	cursorVersion    = "v1"
+	cursorParts      = 3
+)
+
+var errMalformedCursor = errors.New("malformed cursor")
+
+// Cursor is the position of the last row of a page in the (created_at, id) ordering.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        int
+}
+
+// Encode returns the opaque form of the cursor handed out to clients.
+func (c Cursor) Encode() string {
+	raw := fmt.Sprintf("%s:%d:%d", cursorVersion, c.CreatedAt.UnixMicro(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(encoded string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errMalformedCursor, err)
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != cursorParts || parts[0] != cursorVersion {
+		return nil, errMalformedCursor
+	}
+	micros, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errMalformedCursor, err)
+	}
+	id, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errMalformedCursor, err)
+	}
+	return &Cursor{CreatedAt: time.UnixMicro(micros).UTC(), ID: id}, nil
+}
+
+// PageQuery holds the pagination and date filters shared by list endpoints.
+// After is decoded from Cursor by the service before the query reaches storage.
+type PageQuery struct {
+	UserID int
+	Limit  int
+	Cursor string
+	Sort   string
+	From   *time.Time
+	To     *time.Time
+	After  *Cursor
+}
+
+type OrderQuery struct {
+	PageQuery
+	Statuses []string
+}
+
+type WithdrawalQuery struct {
+	PageQuery
+}
+
+type OrderPage struct {
+	Orders     OrderOutList
+	NextCursor string
+}
+
+type WithdrawalPage struct {
+	Withdrawals WithdrawOutList
+	NextCursor  string
+}
+
+func OrderStatuses() []string {
+	return []string{New, Registered, Processing, Invalid, Processed}
+}
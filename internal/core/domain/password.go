@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+type PasswordForgotIn struct {
+	Login string `json:"login"`
+}
+
+type PasswordResetIn struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type PasswordResetToken struct {
+	UserID    int
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
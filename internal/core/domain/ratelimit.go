@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+// RateLimitResult is the state of a rate limit window right after a request was counted.
+type RateLimitResult struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+	Allowed   bool
+}
+
+// NewRateLimitResult derives the result of the hits-th request in the window ending at reset.
+func NewRateLimitResult(limit, hits int, reset time.Time) *RateLimitResult {
+	return &RateLimitResult{
+		Limit:     limit,
+		Remaining: max(limit-hits, 0),
+		Reset:     reset,
+		Allowed:   hits <= limit,
+	}
+}
@@ -0,0 +1,34 @@
+package domain
+
+import "time"
+
+const (
+	StatementAccrual    = "accrual"
+	StatementWithdrawal = "withdrawal"
+	StatementAdjustment = "adjustment"
+)
+
+type StatementQuery struct {
+	UserID int
+	From   *time.Time
+	To     *time.Time
+}
+
+// StatementLine is a credit (positive amount) or debit (negative amount)
+// with the balance right after it.
+type StatementLine struct {
+	Type        string    `json:"type"`
+	Order       string    `json:"order,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Amount      float32   `json:"amount"`
+	Balance     float32   `json:"balance"`
+	Date        time.Time `json:"date"`
+}
+
+type Statement struct {
+	From           *time.Time      `json:"from,omitempty"`
+	To             *time.Time      `json:"to,omitempty"`
+	OpeningBalance float32         `json:"opening_balance"`
+	ClosingBalance float32         `json:"closing_balance"`
+	Lines          []StatementLine `json:"lines"`
+}
@@ -2,11 +2,43 @@ package domain
 
 import "github.com/dgrijalva/jwt-go"
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+const TokenPurposeMFA = "mfa"
+
+const (
+	ScopeOrdersRead   = "orders:read"
+	ScopeOrdersWrite  = "orders:write"
+	ScopeBalanceRead  = "balance:read"
+	ScopeBalanceWrite = "balance:write"
+	ScopeAdminRead    = "admin:read"
+	ScopeAdminWrite   = "admin:write"
+	ScopeAPIKeys      = "api_keys"
+	ScopeAccount      = "account"
+)
+
 type TokenClaims struct {
 	jwt.StandardClaims
-	UserID int `json:"user_id"`
+	UserID  int      `json:"user_id"`
+	Role    string   `json:"role"`
+	Scopes  []string `json:"scopes"`
+	MFAAt   int64    `json:"mfa_at,omitempty"`
+	Purpose string   `json:"purpose,omitempty"`
 }
 
 type Token struct {
-	Token string `json:"token"`
+	Token       string `json:"token,omitempty"`
+	MFARequired bool   `json:"mfa_required,omitempty"`
+	MFAToken    string `json:"mfa_token,omitempty"`
+}
+
+func ScopesForRole(role string) []string {
+	scopes := []string{ScopeOrdersRead, ScopeOrdersWrite, ScopeBalanceRead, ScopeBalanceWrite, ScopeAPIKeys, ScopeAccount}
+	if role == RoleAdmin {
+		scopes = append(scopes, ScopeAdminRead, ScopeAdminWrite)
+	}
+	return scopes
 }
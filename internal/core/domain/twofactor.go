@@ -0,0 +1,33 @@
+package domain
+
+const RecoveryCodesCount = 10
+
+type TOTP struct {
+	Secret  string
+	Enabled bool
+}
+
+// SecondFactor is a code that matched but is not used up yet: either the time step of a TOTP code
+// or the hash of a recovery code, which is only checked when it is used.
+type SecondFactor struct {
+	TOTPCounter      int64
+	RecoveryCodeHash string
+}
+
+type TOTPEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type TOTPCodeIn struct {
+	Code string `json:"code"`
+}
+
+type MFALoginIn struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
@@ -10,9 +10,11 @@ type BalanceOut struct {
 type WithdrawalIn struct {
 	OrderNumber string  `json:"order"`
 	Sum         float32 `json:"sum"`
+	OTPCode     string  `json:"-"`
 }
 
 type WithdrawalsOut struct {
+	ID          int       `json:"-"`
 	Order       string    `json:"order"`
 	Sum         float32   `json:"sum"`
 	ProcessedAt time.Time `json:"processed_at"`
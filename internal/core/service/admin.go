@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"gophermart/internal/adapters/storage"
+	"gophermart/internal/config"
+	"gophermart/internal/core/domain"
+	"gophermart/internal/tracing"
+)
+
+type AdminService struct {
+	storage storage.Admin
+	config  *config.Config
+}
+
+func newAdminService(storage storage.Admin, config *config.Config) *AdminService {
+	return &AdminService{storage: storage, config: config}
+}
+
+func (as *AdminService) FindUserByLogin(ctx context.Context, adminID int, login string) (*domain.UserOut, error) {
+	ctx, span := tracing.Start(ctx, "AdminService.FindUserByLogin")
+	defer span.End()
+	if err := as.audit(ctx, adminID, domain.AuditFindUserByLogin, nil, fmt.Sprintf("login=%s", login)); err != nil {
+		return nil, err
+	}
+	user, err := as.storage.GetUserByLogin(ctx, login)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user by login: %w", err)
+	}
+	return user, nil
+}
+
+func (as *AdminService) GetUser(ctx context.Context, adminID, userID int) (*domain.UserOut, error) {
+	ctx, span := tracing.Start(ctx, "AdminService.GetUser")
+	defer span.End()
+	if err := as.audit(ctx, adminID, domain.AuditGetUser, &userID, ""); err != nil {
+		return nil, err
+	}
+	user, err := as.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user %d: %w", userID, err)
+	}
+	return user, nil
+}
+
+func (as *AdminService) GetUserOrders(
+	ctx context.Context,
+	adminID int,
+	query *domain.OrderQuery,
+) (*domain.OrderPage, error) {
+	ctx, span := tracing.Start(ctx, "AdminService.GetUserOrders")
+	defer span.End()
+	if err := as.audit(ctx, adminID, domain.AuditGetUserOrders, &query.UserID, ""); err != nil {
+		return nil, err
+	}
+	page, err := listOrders(ctx, as.storage, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orders for user %d: %w", query.UserID, err)
+	}
+	return page, nil
+}
+
+func (as *AdminService) GetUserWithdrawals(
+	ctx context.Context,
+	adminID int,
+	query *domain.WithdrawalQuery,
+) (*domain.WithdrawalPage, error) {
+	ctx, span := tracing.Start(ctx, "AdminService.GetUserWithdrawals")
+	defer span.End()
+	if err := as.audit(ctx, adminID, domain.AuditGetUserWithdrawals, &query.UserID, ""); err != nil {
+		return nil, err
+	}
+	page, err := listWithdrawals(ctx, as.storage, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get withdrawals for user %d: %w", query.UserID, err)
+	}
+	return page, nil
+}
+
+func (as *AdminService) GetUserBalance(ctx context.Context, adminID, userID int) (*domain.BalanceOut, error) {
+	ctx, span := tracing.Start(ctx, "AdminService.GetUserBalance")
+	defer span.End()
+	if err := as.audit(ctx, adminID, domain.AuditGetUserBalance, &userID, ""); err != nil {
+		return nil, err
+	}
+	balance, err := as.storage.GetBalance(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance for user %d: %w", userID, err)
+	}
+	return balance, nil
+}
+
+// RequeueOrder is audited in the same transaction, so only requeues that happened are recorded.
+func (as *AdminService) RequeueOrder(ctx context.Context, adminID int, number string) error {
+	ctx, span := tracing.Start(ctx, "AdminService.RequeueOrder")
+	defer span.End()
+	record := auditRecord(ctx, adminID, domain.AuditRequeueOrder, nil, fmt.Sprintf("order=%s", number))
+	if err := as.storage.RequeueOrder(ctx, number, record); err != nil {
+		return fmt.Errorf("failed to requeue order %s: %w", number, err)
+	}
+	return nil
+}
+
+// AdjustBalance is audited in the same transaction, so only adjustments that happened are recorded.
+func (as *AdminService) AdjustBalance(
+	ctx context.Context,
+	adminID, userID int,
+	adjustment *domain.BalanceAdjustmentIn,
+) error {
+	ctx, span := tracing.Start(ctx, "AdminService.AdjustBalance")
+	defer span.End()
+	details := fmt.Sprintf("sum=%.2f reason=%s", adjustment.Sum, adjustment.Reason)
+	record := auditRecord(ctx, adminID, domain.AuditAdjustBalance, &userID, details)
+	if err := as.storage.AdjustBalance(ctx, userID, adjustment, record); err != nil {
+		return fmt.Errorf("failed to adjust balance for user %d: %w", userID, err)
+	}
+	return nil
+}
+
+func (as *AdminService) audit(ctx context.Context, adminID int, action string, targetUserID *int, details string) error {
+	err := as.storage.CreateAuditRecord(ctx, auditRecord(ctx, adminID, action, targetUserID, details))
+	if err != nil {
+		return fmt.Errorf("failed to audit admin action %s: %w", action, err)
+	}
+	return nil
+}
+
+func auditRecord(
+	ctx context.Context,
+	adminID int,
+	action string,
+	targetUserID *int,
+	details string,
+) *domain.AuditRecord {
+	record := &domain.AuditRecord{
+		AdminID:      adminID,
+		Action:       action,
+		TargetUserID: targetUserID,
+		Details:      details,
+	}
+	if principal, ok := domain.PrincipalFromContext(ctx); ok {
+		record.SessionID = principal.SessionID
+		record.AuthMethod = principal.AuthMethod
+	}
+	return record
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"gophermart/internal/adapters/storage"
+	"gophermart/internal/config"
+	"gophermart/internal/core/domain"
+	"gophermart/internal/errs"
+	"gophermart/internal/shared-kernel/hash"
+	"gophermart/internal/tracing"
+	"slices"
+	"strings"
+)
+
+const (
+	apiKeyPrefixBytes = 4
+	apiKeySecretBytes = 32
+)
+
+type APIKeyService struct {
+	storage storage.APIKey
+	config  *config.Config
+}
+
+func newAPIKeyService(storage storage.APIKey, config *config.Config) *APIKeyService {
+	return &APIKeyService{storage: storage, config: config}
+}
+
+func (ks *APIKeyService) CreateAPIKey(
+	ctx context.Context,
+	userID int,
+	keyIn *domain.APIKeyIn,
+) (*domain.APIKeyCreated, error) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.CreateAPIKey")
+	defer span.End()
+	for _, scope := range keyIn.Scopes {
+		if !slices.Contains(domain.APIKeyScopes(), scope) {
+			return nil, errs.NewFieldError("scopes", fmt.Sprintf("scope %s can not be granted to api key", scope))
+		}
+	}
+	prefix, err := randomString(apiKeyPrefixBytes, hex.EncodeToString)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := randomString(apiKeySecretBytes, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return nil, err
+	}
+	rawKey := fmt.Sprintf("%s%s_%s", domain.APIKeyPrefix, prefix, secret)
+	scopes := slices.Clone(keyIn.Scopes)
+	slices.Sort(scopes)
+	key := domain.APIKey{
+		UserID:  userID,
+		Name:    keyIn.Name,
+		Prefix:  domain.APIKeyPrefix + prefix,
+		KeyHash: hash.Encode([]byte(rawKey), ks.config.HashKey),
+		Scopes:  slices.Compact(scopes),
+	}
+	if err = ks.storage.CreateAPIKey(ctx, &key); err != nil {
+		return nil, fmt.Errorf("failed to create api key for user %d: %w", userID, err)
+	}
+	return &domain.APIKeyCreated{APIKey: key, Key: rawKey}, nil
+}
+
+func (ks *APIKeyService) GetAllAPIKeys(ctx context.Context, userID int) (domain.APIKeyList, error) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.GetAllAPIKeys")
+	defer span.End()
+	keys, err := ks.storage.GetAllAPIKeys(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api keys for user %d: %w", userID, err)
+	}
+	return keys, nil
+}
+
+func (ks *APIKeyService) RevokeAPIKey(ctx context.Context, userID, keyID int) error {
+	ctx, span := tracing.Start(ctx, "APIKeyService.RevokeAPIKey")
+	defer span.End()
+	if err := ks.storage.RevokeAPIKey(ctx, userID, keyID); err != nil {
+		return fmt.Errorf("failed to revoke api key %d for user %d: %w", keyID, userID, err)
+	}
+	return nil
+}
+
+func (ks *APIKeyService) ParseAPIKey(ctx context.Context, rawKey string) (*domain.Principal, error) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.ParseAPIKey")
+	defer span.End()
+	if !strings.HasPrefix(rawKey, domain.APIKeyPrefix) {
+		return nil, errors.New("api key has invalid format")
+	}
+	key, err := ks.storage.UseAPIKey(ctx, hash.Encode([]byte(rawKey), ks.config.HashKey))
+	if err != nil {
+		return nil, fmt.Errorf("could not find api key: %w", err)
+	}
+	return &domain.Principal{
+		UserID:     key.UserID,
+		SessionID:  fmt.Sprintf("api_key:%d", key.ID),
+		Role:       domain.RoleUser,
+		Scopes:     key.Scopes,
+		AuthMethod: domain.AuthMethodAPIKey,
+	}, nil
+}
+
+func randomString(size int, encode func([]byte) string) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+	return encode(b), nil
+}
@@ -2,26 +2,36 @@ package service
 
 import (
 	"context"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"gophermart/internal/config"
 	"gophermart/internal/core/domain"
 	"gophermart/internal/shared-kernel/hash"
+	"gophermart/internal/tracing"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 )
 
+const (
+	mfaTokenTTL    = 5 * time.Minute
+	sessionIDBytes = 16
+)
+
 type AuthService struct {
-	storage Storage
-	config  *config.Config
+	storage   Storage
+	config    *config.Config
+	twoFactor *TwoFactorService
 }
 
-func newAuthService(storage Storage, config *config.Config) *AuthService {
-	return &AuthService{storage: storage, config: config}
+func newAuthService(storage Storage, config *config.Config, twoFactor *TwoFactorService) *AuthService {
+	return &AuthService{storage: storage, config: config, twoFactor: twoFactor}
 }
 
 func (auth *AuthService) CreateUser(ctx context.Context, user *domain.UserIn) error {
+	ctx, span := tracing.Start(ctx, "AuthService.CreateUser")
+	defer span.End()
 	user.PasswordHash = hash.Encode([]byte(user.Password), auth.config.HashKey)
 	if err := auth.storage.CreateUser(ctx, user); err != nil {
 		return fmt.Errorf("could not create user: %w", err)
@@ -29,20 +39,113 @@ func (auth *AuthService) CreateUser(ctx context.Context, user *domain.UserIn) er
 	return nil
 }
 
-func (auth *AuthService) CreateToken(ctx context.Context, user *domain.UserIn) (string, error) {
+func (auth *AuthService) CreateToken(ctx context.Context, user *domain.UserIn) (*domain.Token, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.CreateToken")
+	defer span.End()
 	user.PasswordHash = hash.Encode([]byte(user.Password), auth.config.HashKey)
-	userID, err := auth.storage.GetUserID(ctx, user)
+	userOut, err := auth.storage.GetUser(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("could not get user: %w", err)
+	}
+	enabled, err := auth.twoFactor.IsEnabled(ctx, userOut.ID)
+	if err != nil {
+		return nil, fmt.Errorf("could not check second factor: %w", err)
+	}
+	if enabled {
+		tokenID, err := randomString(sessionIDBytes, hex.EncodeToString)
+		if err != nil {
+			return nil, err
+		}
+		mfaToken, err := auth.signToken(&domain.TokenClaims{
+			StandardClaims: jwt.StandardClaims{
+				Id:        tokenID,
+				ExpiresAt: time.Now().Add(mfaTokenTTL).Unix(),
+				IssuedAt:  time.Now().Unix(),
+			},
+			UserID:  userOut.ID,
+			Role:    userOut.Role,
+			Purpose: domain.TokenPurposeMFA,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &domain.Token{MFARequired: true, MFAToken: mfaToken}, nil
+	}
+	return auth.createAccessToken(userOut.ID, userOut.Role, 0)
+}
+
+// CompleteMFALogin accepts an MFA token once, after its second factor has been verified.
+func (auth *AuthService) CompleteMFALogin(ctx context.Context, mfaIn *domain.MFALoginIn) (*domain.Token, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.CompleteMFALogin")
+	defer span.End()
+	claims, err := auth.parseClaims(mfaIn.MFAToken)
 	if err != nil {
-		return "", fmt.Errorf("could not get user id: %w", err)
+		return nil, err
+	}
+	if claims.Purpose != domain.TokenPurposeMFA {
+		return nil, errors.New("token is not an mfa token")
 	}
-	tokenClaim := domain.TokenClaims{
+	if err = auth.twoFactor.VerifySecondFactor(ctx, claims.UserID, mfaIn.Code); err != nil {
+		return nil, fmt.Errorf("could not verify second factor: %w", err)
+	}
+	if claims.Id == "" {
+		return nil, errors.New("mfa token has no id")
+	}
+	if err = auth.storage.UseMFAToken(ctx, claims.Id, time.Unix(claims.ExpiresAt, 0)); err != nil {
+		return nil, fmt.Errorf("could not use mfa token: %w", err)
+	}
+	return auth.createAccessToken(claims.UserID, claims.Role, time.Now().Unix())
+}
+
+func (auth *AuthService) ParseToken(accessToken string) (*domain.Principal, error) {
+	claims, err := auth.parseClaims(accessToken)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != "" {
+		return nil, errors.New("token is not an access token")
+	}
+	if claims.Role == "" {
+		claims.Role = domain.RoleUser
+		claims.Scopes = domain.ScopesForRole(domain.RoleUser)
+	}
+	principal := &domain.Principal{
+		UserID:     claims.UserID,
+		SessionID:  claims.Id,
+		Role:       claims.Role,
+		Scopes:     claims.Scopes,
+		AuthMethod: domain.AuthMethodJWT,
+	}
+	if claims.MFAAt > 0 {
+		principal.MFAAt = time.Unix(claims.MFAAt, 0)
+	}
+	return principal, nil
+}
+
+func (auth *AuthService) createAccessToken(userID int, role string, mfaAt int64) (*domain.Token, error) {
+	sessionID, err := randomString(sessionIDBytes, hex.EncodeToString)
+	if err != nil {
+		return nil, err
+	}
+	signedToken, err := auth.signToken(&domain.TokenClaims{
 		StandardClaims: jwt.StandardClaims{
+			Id:        sessionID,
 			ExpiresAt: time.Now().Add(time.Duration(auth.config.TokenTTLSeconds) * time.Second).Unix(),
 			IssuedAt:  time.Now().Unix(),
 		},
 		UserID: userID,
+		Role:   role,
+		Scopes: domain.ScopesForRole(role),
+		MFAAt:  mfaAt,
+	})
+	if err != nil {
+		return nil, err
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &tokenClaim)
+	return &domain.Token{Token: signedToken}, nil
+}
+
+func (auth *AuthService) signToken(claims *domain.TokenClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	signedToken, err := token.SignedString([]byte(auth.config.TokenKey))
 	if err != nil {
 		return "", fmt.Errorf("could not sign token: %w", err)
@@ -50,22 +153,22 @@ func (auth *AuthService) CreateToken(ctx context.Context, user *domain.UserIn) (
 	return signedToken, nil
 }
 
-func (auth *AuthService) GetUserID(accessToken string) (int, error) {
-	token, err := jwt.ParseWithClaims(accessToken, &domain.TokenClaims{}, func(token *jwt.Token) (interface{}, error) {
+func (auth *AuthService) parseClaims(rawToken string) (*domain.TokenClaims, error) {
+	token, err := jwt.ParseWithClaims(rawToken, &domain.TokenClaims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("invalid signing method")
 		}
 		return []byte(auth.config.TokenKey), nil
 	})
 	if err != nil {
-		return 0, fmt.Errorf("could not parse token: %w", err)
+		return nil, fmt.Errorf("could not parse token: %w", err)
 	}
 	claims, ok := token.Claims.(*domain.TokenClaims)
 	if !ok {
-		return 0, errors.New("token is not valid")
+		return nil, errors.New("token is not valid")
 	}
 	if claims.StandardClaims.ExpiresAt < time.Now().Unix() {
-		return 0, errors.New("token expired")
+		return nil, errors.New("token expired")
 	}
-	return claims.UserID, nil
+	return claims, nil
 }
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"gophermart/internal/adapters/storage"
+	"gophermart/internal/config"
+	"gophermart/internal/core/domain"
+	"gophermart/internal/logger"
+	"gophermart/internal/tracing"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	subscriberBuffer   = 16
+	replayLimit        = 1000
+	listenRetryDelay   = time.Second
+	eventsPruneEvery   = time.Hour
+	listenEventsBuffer = 64
+)
+
+// EventService fans order events received from storage out to the streams of their owners.
+// Every instance listens on its own, so a change made anywhere reaches every connected client.
+type EventService struct {
+	storage storage.OrderEvents
+	config  *config.Config
+
+	mu          sync.Mutex
+	subscribers map[int]map[chan domain.OrderEvent]struct{}
+	stopped     bool
+}
+
+func newEventService(storage storage.OrderEvents, config *config.Config) *EventService {
+	return &EventService{
+		storage:     storage,
+		config:      config,
+		subscribers: make(map[int]map[chan domain.OrderEvent]struct{}),
+	}
+}
+
+// RunOrderEvents listens for order events until ctx is done, reconnecting on failures,
+// and prunes events older than the configured retention. When it returns, every stream is ended,
+// so that open streams don't hold up the shutdown of the API.
+func (es *EventService) RunOrderEvents(ctx context.Context) error {
+	defer es.stop()
+	events := make(chan domain.OrderEvent, listenEventsBuffer)
+	go es.dispatch(ctx, events)
+	go es.prune(ctx)
+	for {
+		err := es.storage.ListenOrderEvents(ctx, events, es.resync)
+		if ctx.Err() != nil {
+			return nil
+		}
+		logger.Log.Error("order events listener failed, reconnecting", zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(listenRetryDelay):
+		}
+	}
+}
+
+// resync ends the streams that subscribed before the listener was listening. They may have missed
+// events committed in between, which their clients get when they resume with Last-Event-ID.
+func (es *EventService) resync() {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	if len(es.subscribers) > 0 {
+		logger.Log.Info("order events listener is listening, resyncing streams")
+	}
+	es.removeAllLocked()
+}
+
+// stop ends every stream and makes new ones end right away.
+func (es *EventService) stop() {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	es.stopped = true
+	es.removeAllLocked()
+}
+
+func (es *EventService) dispatch(ctx context.Context, events <-chan domain.OrderEvent) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-events:
+			es.publish(event)
+		}
+	}
+}
+
+// publish never blocks: a subscriber that can't keep up is dropped and resumes with Last-Event-ID.
+func (es *EventService) publish(event domain.OrderEvent) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	for ch := range es.subscribers[event.UserID] {
+		select {
+		case ch <- event:
+		default:
+			logger.Log.Info("dropping slow order events subscriber", zap.Int("user_id", event.UserID))
+			es.removeLocked(event.UserID, ch)
+		}
+	}
+}
+
+func (es *EventService) prune(ctx context.Context) {
+	ticker := time.NewTicker(eventsPruneEvery)
+	defer ticker.Stop()
+	retention := time.Duration(es.config.EventsRetention) * time.Second
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := es.storage.DeleteOrderEventsBefore(ctx, time.Now().Add(-retention)); err != nil {
+				logger.Log.Error("failed to prune order events", zap.Error(err))
+			}
+		}
+	}
+}
+
+// SubscribeOrderEvents streams the events of userID until ctx is done.
+// Events after lastEventID are replayed from storage page by page before live ones.
+// The channel is closed when the stream ends, including when the subscriber falls behind.
+func (es *EventService) SubscribeOrderEvents(
+	ctx context.Context,
+	userID int,
+	lastEventID int64,
+) (<-chan domain.OrderEvent, error) {
+	ctx, span := tracing.Start(ctx, "EventService.SubscribeOrderEvents")
+	defer span.End()
+	live := es.subscribe(userID)
+	var backlog []domain.OrderEvent
+	if lastEventID > 0 {
+		var err error
+		backlog, err = es.storage.GetOrderEventsSince(ctx, userID, lastEventID, replayLimit)
+		if err != nil {
+			es.unsubscribe(userID, live)
+			return nil, fmt.Errorf("failed to replay order events for user %d: %w", userID, err)
+		}
+	}
+	out := make(chan domain.OrderEvent)
+	go func() {
+		defer close(out)
+		defer es.unsubscribe(userID, live)
+		replayed, ok := es.replay(ctx, out, userID, backlog)
+		if !ok {
+			return
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-live:
+				if !ok {
+					return
+				}
+				// Live events committed during the replay were sent with the backlog already.
+				if _, sent := replayed[event.ID]; sent {
+					delete(replayed, event.ID)
+					continue
+				}
+				if !send(ctx, out, event) {
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// replay sends backlog and the pages after it until a short page shows the backlog is exhausted.
+// It returns the ids it sent, or false when the stream has to end.
+func (es *EventService) replay(
+	ctx context.Context,
+	out chan<- domain.OrderEvent,
+	userID int,
+	backlog []domain.OrderEvent,
+) (map[int64]struct{}, bool) {
+	replayed := make(map[int64]struct{})
+	for len(backlog) > 0 {
+		for _, event := range backlog {
+			if !send(ctx, out, event) {
+				return nil, false
+			}
+			replayed[event.ID] = struct{}{}
+		}
+		if len(backlog) < replayLimit {
+			break
+		}
+		var err error
+		backlog, err = es.storage.GetOrderEventsSince(ctx, userID, backlog[len(backlog)-1].ID, replayLimit)
+		if err != nil {
+			// The client resumes from the last event it got when it reconnects.
+			logger.FromContext(ctx).Error("failed to replay order events", zap.Error(err))
+			return nil, false
+		}
+	}
+	return replayed, true
+}
+
+func send(ctx context.Context, out chan<- domain.OrderEvent, event domain.OrderEvent) bool {
+	select {
+	case out <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (es *EventService) subscribe(userID int) chan domain.OrderEvent {
+	ch := make(chan domain.OrderEvent, subscriberBuffer)
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	if es.stopped {
+		close(ch)
+		return ch
+	}
+	if es.subscribers[userID] == nil {
+		es.subscribers[userID] = make(map[chan domain.OrderEvent]struct{})
+	}
+	es.subscribers[userID][ch] = struct{}{}
+	return ch
+}
+
+func (es *EventService) unsubscribe(userID int, ch chan domain.OrderEvent) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	es.removeLocked(userID, ch)
+}
+
+func (es *EventService) removeLocked(userID int, ch chan domain.OrderEvent) {
+	if _, ok := es.subscribers[userID][ch]; !ok {
+		return
+	}
+	delete(es.subscribers[userID], ch)
+	close(ch)
+	if len(es.subscribers[userID]) == 0 {
+		delete(es.subscribers, userID)
+	}
+}
+
+func (es *EventService) removeAllLocked() {
+	for userID, channels := range es.subscribers {
+		for ch := range channels {
+			close(ch)
+		}
+		delete(es.subscribers, userID)
+	}
+}
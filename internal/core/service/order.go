@@ -7,6 +7,7 @@ import (
 	"gophermart/internal/adapters/storage"
 	"gophermart/internal/config"
 	"gophermart/internal/errs"
+	"gophermart/internal/tracing"
 
 	"gophermart/internal/core/domain"
 
@@ -23,6 +24,8 @@ func newOrderService(storage storage.Order, config *config.Config) *OrderService
 }
 
 func (o *OrderService) CreateOrder(ctx context.Context, userID int, order *domain.OrderIn) error {
+	ctx, span := tracing.Start(ctx, "OrderService.CreateOrder")
+	defer span.End()
 	if err := goluhn.Validate(order.Number); err != nil {
 		return errs.ErrInvalidOrderNumber
 	}
@@ -44,10 +47,91 @@ func (o *OrderService) CreateOrder(ctx context.Context, userID int, order *domai
 	return nil
 }
 
-func (o *OrderService) GetAllOrders(ctx context.Context, userID int) (domain.OrderOutList, error) {
-	orders, err := o.storage.GetAllOrders(ctx, userID)
+// CreateOrders uploads numbers in a single batch and reports a result per number in input order.
+// Numbers repeated within the batch are reported as already uploaded after their first occurrence.
+func (o *OrderService) CreateOrders(
+	ctx context.Context,
+	userID int,
+	numbers []string,
+) (domain.BulkOrderResults, error) {
+	ctx, span := tracing.Start(ctx, "OrderService.CreateOrders")
+	defer span.End()
+	results := make(domain.BulkOrderResults, len(numbers))
+	seen := make(map[string]struct{}, len(numbers))
+	valid := make([]string, 0, len(numbers))
+	for i, number := range numbers {
+		results[i].Number = number
+		if err := goluhn.Validate(number); err != nil {
+			results[i].Result = domain.BulkInvalidNumber
+			continue
+		}
+		if _, ok := seen[number]; ok {
+			results[i].Result = domain.BulkAlreadyUploaded
+			continue
+		}
+		seen[number] = struct{}{}
+		valid = append(valid, number)
+	}
+	if len(valid) == 0 {
+		return results, nil
+	}
+	owners, err := o.storage.CreateOrders(ctx, userID, valid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create orders for user %d: %w", userID, err)
+	}
+	for i := range results {
+		if results[i].Result != "" {
+			continue
+		}
+		owner, exists := owners[results[i].Number]
+		switch {
+		case !exists:
+			results[i].Result = domain.BulkAccepted
+		case owner == userID:
+			results[i].Result = domain.BulkAlreadyUploaded
+		default:
+			results[i].Result = domain.BulkOwnedByAnotherUser
+		}
+	}
+	return results, nil
+}
+
+func (o *OrderService) GetAllOrders(ctx context.Context, query *domain.OrderQuery) (*domain.OrderPage, error) {
+	ctx, span := tracing.Start(ctx, "OrderService.GetAllOrders")
+	defer span.End()
+	page, err := listOrders(ctx, o.storage, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orders for user %d: %w", query.UserID, err)
+	}
+	return page, nil
+}
+
+// GetOrder returns an order of the user. Orders of other users are reported as not found
+// so the endpoint can't be used to probe which numbers exist.
+func (o *OrderService) GetOrder(ctx context.Context, userID int, number string) (*domain.OrderOut, error) {
+	ctx, span := tracing.Start(ctx, "OrderService.GetOrder")
+	defer span.End()
+	order, err := o.storage.GetOrder(ctx, &domain.OrderIn{Number: number})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get all orders: %w", err)
+		return nil, fmt.Errorf("failed to get order %s: %w", number, err)
 	}
-	return orders, nil
+	if order.UserID != userID {
+		return nil, fmt.Errorf("order %s is not owned by user %d: %w", number, userID, errs.ErrNotFound)
+	}
+	return order, nil
+}
+
+// ExportOrders streams every order matching the filters of query, ignoring its paging.
+func (o *OrderService) ExportOrders(
+	ctx context.Context,
+	query *domain.OrderQuery,
+	fn func(order *domain.OrderRecord) error,
+) error {
+	ctx, span := tracing.Start(ctx, "OrderService.ExportOrders")
+	defer span.End()
+	query.Limit, query.Cursor, query.After = 0, "", nil
+	if err := o.storage.StreamOrders(ctx, query, fn); err != nil {
+		return fmt.Errorf("failed to export orders for user %d: %w", query.UserID, err)
+	}
+	return nil
 }
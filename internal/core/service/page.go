@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"gophermart/internal/core/domain"
+	"gophermart/internal/errs"
+)
+
+type orderLister interface {
+	GetAllOrders(ctx context.Context, query *domain.OrderQuery) (domain.OrderOutList, error)
+}
+
+type withdrawalLister interface {
+	GetAllWithdrawals(ctx context.Context, query *domain.WithdrawalQuery) (domain.WithdrawOutList, error)
+}
+
+// preparePage applies the default limit and sort and decodes the client cursor.
+func preparePage(query *domain.PageQuery) error {
+	if query.Limit == 0 {
+		query.Limit = domain.DefaultPageLimit
+	}
+	if query.Sort == "" {
+		query.Sort = domain.SortAsc
+	}
+	if query.Cursor != "" {
+		after, err := domain.DecodeCursor(query.Cursor)
+		if err != nil {
+			return errs.NewFieldError("cursor", "is malformed")
+		}
+		query.After = after
+	}
+	return nil
+}
+
+// listOrders fetches one row past the limit to learn whether a next page exists.
+func listOrders(ctx context.Context, lister orderLister, query *domain.OrderQuery) (*domain.OrderPage, error) {
+	if err := preparePage(&query.PageQuery); err != nil {
+		return nil, err
+	}
+	fetch := *query
+	fetch.Limit++
+	orders, err := lister.GetAllOrders(ctx, &fetch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orders: %w", err)
+	}
+	page := &domain.OrderPage{Orders: orders}
+	if len(orders) > query.Limit {
+		page.Orders = orders[:query.Limit]
+		last := page.Orders[query.Limit-1]
+		page.NextCursor = domain.Cursor{CreatedAt: last.UploadedAt, ID: last.ID}.Encode()
+	}
+	return page, nil
+}
+
+func listWithdrawals(
+	ctx context.Context,
+	lister withdrawalLister,
+	query *domain.WithdrawalQuery,
+) (*domain.WithdrawalPage, error) {
+	if err := preparePage(&query.PageQuery); err != nil {
+		return nil, err
+	}
+	fetch := *query
+	fetch.Limit++
+	withdrawals, err := lister.GetAllWithdrawals(ctx, &fetch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list withdrawals: %w", err)
+	}
+	page := &domain.WithdrawalPage{Withdrawals: withdrawals}
+	if len(withdrawals) > query.Limit {
+		page.Withdrawals = withdrawals[:query.Limit]
+		last := page.Withdrawals[query.Limit-1]
+		page.NextCursor = domain.Cursor{CreatedAt: last.ProcessedAt, ID: last.ID}.Encode()
+	}
+	return page, nil
+}
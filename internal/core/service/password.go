@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"gophermart/internal/adapters/mailer"
+	"gophermart/internal/adapters/storage"
+	"gophermart/internal/config"
+	"gophermart/internal/core/domain"
+	"gophermart/internal/errs"
+	"gophermart/internal/logger"
+	"gophermart/internal/shared-kernel/hash"
+	"gophermart/internal/tracing"
+	"net/mail"
+	"net/url"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	resetTokenBytes  = 32
+	resetLinkTimeout = 30 * time.Second
+)
+
+type PasswordService struct {
+	storage storage.Password
+	mailer  mailer.Mailer
+	config  *config.Config
+}
+
+func newPasswordService(storage storage.Password, mailer mailer.Mailer, config *config.Config) *PasswordService {
+	return &PasswordService{storage: storage, mailer: mailer, config: config}
+}
+
+// ForgotPassword sends a reset link to the user. Unknown logins and logins that are not email addresses
+// are ignored and the link is sent in the background, so that neither the response nor its timing
+// reveals which accounts exist.
+func (ps *PasswordService) ForgotPassword(ctx context.Context, forgot *domain.PasswordForgotIn) error {
+	ctx, span := tracing.Start(ctx, "PasswordService.ForgotPassword")
+	defer span.End()
+	user, err := ps.storage.GetUserByLogin(ctx, forgot.Login)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			logger.FromContext(ctx).Info("password reset requested for unknown login")
+			return nil
+		}
+		return fmt.Errorf("failed to get user by login: %w", err)
+	}
+	if !isEmailAddress(user.Login) {
+		logger.FromContext(ctx).Info("password reset requested for a login that is not an email address",
+			zap.Int("user_id", user.ID))
+		return nil
+	}
+	go func() {
+		sendCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), resetLinkTimeout)
+		defer cancel()
+		if sendErr := ps.sendResetLink(sendCtx, user); sendErr != nil {
+			logger.FromContext(sendCtx).Error("failed to send password reset link", zap.Error(sendErr))
+		}
+	}()
+	return nil
+}
+
+// isEmailAddress reports whether login is a bare address the reset link can be mailed to.
+func isEmailAddress(login string) bool {
+	address, err := mail.ParseAddress(login)
+	return err == nil && address.Address == login
+}
+
+func (ps *PasswordService) sendResetLink(ctx context.Context, user *domain.UserOut) error {
+	ctx, span := tracing.Start(ctx, "PasswordService.sendResetLink")
+	defer span.End()
+	token, err := randomString(resetTokenBytes, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return err
+	}
+	err = ps.storage.CreatePasswordResetToken(ctx, &domain.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hash.Encode([]byte(token), ps.config.HashKey),
+		ExpiresAt: time.Now().Add(time.Duration(ps.config.PasswordResetTTL) * time.Second),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create password reset token for user %d: %w", user.ID, err)
+	}
+	link := fmt.Sprintf("%s?token=%s", ps.config.PasswordResetURL, url.QueryEscape(token))
+	err = ps.mailer.Send(ctx, &domain.Message{
+		To:      user.Login,
+		Subject: "Gophermart password reset",
+		Body: fmt.Sprintf(
+			"Follow the link to set a new password: %s\nThe link expires in %d minutes.",
+			link,
+			ps.config.PasswordResetTTL/int(time.Minute/time.Second),
+		),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send password reset mail to user %d: %w", user.ID, err)
+	}
+	return nil
+}
+
+// ResetPassword sets the new password and revokes the user's API keys. Issued JWTs stay valid until
+// they expire, as there is no session store to revoke them in.
+func (ps *PasswordService) ResetPassword(ctx context.Context, reset *domain.PasswordResetIn) error {
+	ctx, span := tracing.Start(ctx, "PasswordService.ResetPassword")
+	defer span.End()
+	tokenHash := hash.Encode([]byte(reset.Token), ps.config.HashKey)
+	passwordHash := hash.Encode([]byte(reset.Password), ps.config.HashKey)
+	if err := ps.storage.ResetPassword(ctx, tokenHash, passwordHash); err != nil {
+		return fmt.Errorf("failed to reset password: %w", err)
+	}
+	return nil
+}
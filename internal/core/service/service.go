@@ -2,50 +2,142 @@ package service
 
 import (
 	"context"
+	"gophermart/internal/adapters/mailer"
 	"gophermart/internal/config"
 	"gophermart/internal/core/domain"
+	"time"
 )
 
 type Storage interface {
 	CreateUser(ctx context.Context, user *domain.UserIn) error
-	GetUserID(ctx context.Context, user *domain.UserIn) (int, error)
+	GetUser(ctx context.Context, user *domain.UserIn) (*domain.User, error)
+	UseMFAToken(ctx context.Context, tokenID string, expiresAt time.Time) error
 	CreateOrder(ctx context.Context, userID int, order *domain.OrderIn) error
+	CreateOrders(ctx context.Context, userID int, numbers []string) (map[string]int, error)
 	UpdateOrder(ctx context.Context, order *domain.AccrualOut) error
 	GetOrder(ctx context.Context, order *domain.OrderIn) (*domain.OrderOut, error)
-	GetAllOrders(ctx context.Context, userID int) (domain.OrderOutList, error)
+	GetAllOrders(ctx context.Context, query *domain.OrderQuery) (domain.OrderOutList, error)
 	GetAllOrdersByStatus(ctx context.Context, status string) (domain.OrderOutList, error)
 	GetBalance(ctx context.Context, userID int) (*domain.BalanceOut, error)
-	WithdrawBonuses(ctx context.Context, userID int, withdraw *domain.WithdrawalIn) error
-	GetAllWithdrawals(ctx context.Context, userID int) (domain.WithdrawOutList, error)
+	WithdrawBonuses(ctx context.Context, userID int, withdraw *domain.WithdrawalIn, factor *domain.SecondFactor) error
+	GetAllWithdrawals(ctx context.Context, query *domain.WithdrawalQuery) (domain.WithdrawOutList, error)
+	GetStatement(ctx context.Context, query *domain.StatementQuery) (*domain.Statement, error)
+	GetUserByID(ctx context.Context, userID int) (*domain.UserOut, error)
+	GetUserByLogin(ctx context.Context, login string) (*domain.UserOut, error)
+	RequeueOrder(ctx context.Context, number string, audit *domain.AuditRecord) error
+	AdjustBalance(
+		ctx context.Context,
+		userID int,
+		adjustment *domain.BalanceAdjustmentIn,
+		audit *domain.AuditRecord,
+	) error
+	CreateAuditRecord(ctx context.Context, record *domain.AuditRecord) error
+	CreateAPIKey(ctx context.Context, key *domain.APIKey) error
+	GetAllAPIKeys(ctx context.Context, userID int) (domain.APIKeyList, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID int) error
+	UseAPIKey(ctx context.Context, keyHash string) (*domain.APIKey, error)
+	GetTOTP(ctx context.Context, userID int) (*domain.TOTP, error)
+	SetTOTPSecret(ctx context.Context, userID int, secret string) error
+	EnableTOTP(ctx context.Context, userID int, counter int64, recoveryCodeHashes []string) error
+	DisableTOTP(ctx context.Context, userID int) error
+	UseTOTPCounter(ctx context.Context, userID int, counter int64) error
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) error
+	CreatePasswordResetToken(ctx context.Context, token *domain.PasswordResetToken) error
+	ResetPassword(ctx context.Context, tokenHash, passwordHash string) error
+	ListenOrderEvents(ctx context.Context, events chan<- domain.OrderEvent, listening func()) error
+	GetOrderEventsSince(ctx context.Context, userID int, afterID int64, limit int) ([]domain.OrderEvent, error)
+	DeleteOrderEventsBefore(ctx context.Context, before time.Time) error
+	StreamOrders(ctx context.Context, query *domain.OrderQuery, fn func(order *domain.OrderRecord) error) error
+	StreamStatement(ctx context.Context, query *domain.StatementQuery, fn func(entry *domain.LedgerEntry) error) error
+	StreamWithdrawals(
+		ctx context.Context,
+		query *domain.WithdrawalQuery,
+		fn func(withdrawal *domain.WithdrawalRecord) error,
+	) error
 }
 
 type Authorization interface {
 	CreateUser(ctx context.Context, user *domain.UserIn) error
-	CreateToken(ctx context.Context, user *domain.UserIn) (string, error)
-	GetUserID(accessToken string) (int, error)
+	CreateToken(ctx context.Context, user *domain.UserIn) (*domain.Token, error)
+	CompleteMFALogin(ctx context.Context, mfaIn *domain.MFALoginIn) (*domain.Token, error)
+	ParseToken(accessToken string) (*domain.Principal, error)
 }
 
 type Order interface {
 	CreateOrder(ctx context.Context, userID int, order *domain.OrderIn) error
-	GetAllOrders(ctx context.Context, userID int) (domain.OrderOutList, error)
+	CreateOrders(ctx context.Context, userID int, numbers []string) (domain.BulkOrderResults, error)
+	GetAllOrders(ctx context.Context, query *domain.OrderQuery) (*domain.OrderPage, error)
+	GetOrder(ctx context.Context, userID int, number string) (*domain.OrderOut, error)
+	ExportOrders(ctx context.Context, query *domain.OrderQuery, fn func(order *domain.OrderRecord) error) error
 }
 
 type Withdrawal interface {
 	GetBalance(ctx context.Context, userID int) (*domain.BalanceOut, error)
 	WithdrawBonuses(ctx context.Context, userID int, withdraw *domain.WithdrawalIn) error
-	GetAllWithdrawals(ctx context.Context, userID int) (domain.WithdrawOutList, error)
+	GetAllWithdrawals(ctx context.Context, query *domain.WithdrawalQuery) (*domain.WithdrawalPage, error)
+	GetStatement(ctx context.Context, query *domain.StatementQuery) (*domain.Statement, error)
+	ExportStatement(ctx context.Context, query *domain.StatementQuery, fn func(entry *domain.LedgerEntry) error) error
+	ExportWithdrawals(
+		ctx context.Context,
+		query *domain.WithdrawalQuery,
+		fn func(withdrawal *domain.WithdrawalRecord) error,
+	) error
+}
+
+type Admin interface {
+	FindUserByLogin(ctx context.Context, adminID int, login string) (*domain.UserOut, error)
+	GetUser(ctx context.Context, adminID, userID int) (*domain.UserOut, error)
+	GetUserOrders(ctx context.Context, adminID int, query *domain.OrderQuery) (*domain.OrderPage, error)
+	GetUserWithdrawals(ctx context.Context, adminID int, query *domain.WithdrawalQuery) (*domain.WithdrawalPage, error)
+	GetUserBalance(ctx context.Context, adminID, userID int) (*domain.BalanceOut, error)
+	RequeueOrder(ctx context.Context, adminID int, number string) error
+	AdjustBalance(ctx context.Context, adminID, userID int, adjustment *domain.BalanceAdjustmentIn) error
+}
+
+type APIKey interface {
+	CreateAPIKey(ctx context.Context, userID int, keyIn *domain.APIKeyIn) (*domain.APIKeyCreated, error)
+	GetAllAPIKeys(ctx context.Context, userID int) (domain.APIKeyList, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID int) error
+	ParseAPIKey(ctx context.Context, rawKey string) (*domain.Principal, error)
+}
+
+type TwoFactor interface {
+	EnrollTOTP(ctx context.Context, userID int) (*domain.TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, userID int, code string) (*domain.RecoveryCodes, error)
+	DisableTOTP(ctx context.Context, userID int, code string) error
+}
+
+type Password interface {
+	ForgotPassword(ctx context.Context, forgot *domain.PasswordForgotIn) error
+	ResetPassword(ctx context.Context, reset *domain.PasswordResetIn) error
+}
+
+type OrderEvents interface {
+	SubscribeOrderEvents(ctx context.Context, userID int, lastEventID int64) (<-chan domain.OrderEvent, error)
+	RunOrderEvents(ctx context.Context) error
 }
 
 type Service struct {
 	Authorization
 	Order
 	Withdrawal
+	Admin
+	APIKey
+	TwoFactor
+	Password
+	OrderEvents
 }
 
-func NewService(cfg *config.Config, storage Storage) *Service {
+func NewService(cfg *config.Config, storage Storage, mailer mailer.Mailer) *Service {
+	twoFactor := newTwoFactorService(storage, cfg)
 	return &Service{
-		Authorization: newAuthService(storage, cfg),
+		Authorization: newAuthService(storage, cfg, twoFactor),
 		Order:         newOrderService(storage, cfg),
-		Withdrawal:    newWithdrawService(storage, cfg),
+		Withdrawal:    newWithdrawService(storage, cfg, twoFactor),
+		Admin:         newAdminService(storage, cfg),
+		APIKey:        newAPIKeyService(storage, cfg),
+		TwoFactor:     twoFactor,
+		Password:      newPasswordService(storage, mailer, cfg),
+		OrderEvents:   newEventService(storage, cfg),
 	}
 }
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"gophermart/internal/adapters/storage"
+	"gophermart/internal/config"
+	"gophermart/internal/core/domain"
+	"gophermart/internal/errs"
+	"gophermart/internal/shared-kernel/hash"
+	"gophermart/internal/shared-kernel/totp"
+	"gophermart/internal/tracing"
+	"strings"
+	"time"
+)
+
+const recoveryCodeBytes = 5
+
+type TwoFactorService struct {
+	storage storage.TwoFactor
+	config  *config.Config
+}
+
+func newTwoFactorService(storage storage.TwoFactor, config *config.Config) *TwoFactorService {
+	return &TwoFactorService{storage: storage, config: config}
+}
+
+func (tf *TwoFactorService) EnrollTOTP(ctx context.Context, userID int) (*domain.TOTPEnrollment, error) {
+	ctx, span := tracing.Start(ctx, "TwoFactorService.EnrollTOTP")
+	defer span.End()
+	user, err := tf.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user %d: %w", userID, err)
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to enroll totp: %w", err)
+	}
+	if err = tf.storage.SetTOTPSecret(ctx, userID, secret); err != nil {
+		return nil, fmt.Errorf("failed to enroll totp for user %d: %w", userID, err)
+	}
+	return &domain.TOTPEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(tf.config.TOTPIssuer, user.Login, secret),
+	}, nil
+}
+
+func (tf *TwoFactorService) ConfirmTOTP(ctx context.Context, userID int, code string) (*domain.RecoveryCodes, error) {
+	ctx, span := tracing.Start(ctx, "TwoFactorService.ConfirmTOTP")
+	defer span.End()
+	userTOTP, err := tf.storage.GetTOTP(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get totp for user %d: %w", userID, err)
+	}
+	if userTOTP.Enabled {
+		return nil, errs.ErrTwoFactorAlreadyEnabled
+	}
+	if userTOTP.Secret == "" {
+		return nil, errs.ErrTwoFactorNotEnabled
+	}
+	counter, ok := totp.Validate(userTOTP.Secret, code, time.Now())
+	if !ok {
+		return nil, errs.ErrInvalidSecondFactor
+	}
+	codes := make([]string, 0, domain.RecoveryCodesCount)
+	hashes := make([]string, 0, domain.RecoveryCodesCount)
+	for range domain.RecoveryCodesCount {
+		recoveryCode, err := randomString(recoveryCodeBytes, hex.EncodeToString)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, recoveryCode)
+		hashes = append(hashes, hash.Encode([]byte(recoveryCode), tf.config.HashKey))
+	}
+	if err = tf.storage.EnableTOTP(ctx, userID, counter, hashes); err != nil {
+		return nil, fmt.Errorf("failed to enable totp for user %d: %w", userID, err)
+	}
+	return &domain.RecoveryCodes{Codes: codes}, nil
+}
+
+func (tf *TwoFactorService) DisableTOTP(ctx context.Context, userID int, code string) error {
+	ctx, span := tracing.Start(ctx, "TwoFactorService.DisableTOTP")
+	defer span.End()
+	if err := tf.VerifySecondFactor(ctx, userID, code); err != nil {
+		return err
+	}
+	if err := tf.storage.DisableTOTP(ctx, userID); err != nil {
+		return fmt.Errorf("failed to disable totp for user %d: %w", userID, err)
+	}
+	return nil
+}
+
+func (tf *TwoFactorService) IsEnabled(ctx context.Context, userID int) (bool, error) {
+	ctx, span := tracing.Start(ctx, "TwoFactorService.IsEnabled")
+	defer span.End()
+	userTOTP, err := tf.storage.GetTOTP(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get totp for user %d: %w", userID, err)
+	}
+	return userTOTP.Enabled, nil
+}
+
+// VerifySecondFactor accepts either a current TOTP code or an unused recovery code. A TOTP code is
+// accepted once, and neither is a code older than the last one accepted.
+func (tf *TwoFactorService) VerifySecondFactor(ctx context.Context, userID int, code string) error {
+	ctx, span := tracing.Start(ctx, "TwoFactorService.VerifySecondFactor")
+	defer span.End()
+	factor, err := tf.MatchSecondFactor(ctx, userID, code)
+	if err != nil {
+		return err
+	}
+	if factor.RecoveryCodeHash != "" {
+		err = tf.storage.UseRecoveryCode(ctx, userID, factor.RecoveryCodeHash)
+	} else {
+		err = tf.storage.UseTOTPCounter(ctx, userID, factor.TOTPCounter)
+	}
+	if errors.Is(err, errs.ErrNotFound) {
+		return errs.ErrInvalidSecondFactor
+	}
+	if err != nil {
+		return fmt.Errorf("failed to use second factor for user %d: %w", userID, err)
+	}
+	return nil
+}
+
+// MatchSecondFactor checks code without using it up, for callers that record the factor as used
+// in the same transaction as the action it confirms.
+func (tf *TwoFactorService) MatchSecondFactor(
+	ctx context.Context,
+	userID int,
+	code string,
+) (*domain.SecondFactor, error) {
+	userTOTP, err := tf.storage.GetTOTP(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get totp for user %d: %w", userID, err)
+	}
+	if !userTOTP.Enabled {
+		return nil, errs.ErrTwoFactorNotEnabled
+	}
+	code = strings.TrimSpace(code)
+	if counter, ok := totp.Validate(userTOTP.Secret, code, time.Now()); ok {
+		return &domain.SecondFactor{TOTPCounter: counter}, nil
+	}
+	return &domain.SecondFactor{
+		RecoveryCodeHash: hash.Encode([]byte(strings.ToLower(code)), tf.config.HashKey),
+	}, nil
+}
@@ -7,20 +7,29 @@ import (
 	"gophermart/internal/config"
 	"gophermart/internal/core/domain"
 	"gophermart/internal/errs"
+	"gophermart/internal/tracing"
+	"time"
 
 	"github.com/ShiraazMoollatjie/goluhn"
 )
 
 type WithdrawService struct {
-	storage storage.Withdrawal
-	config  *config.Config
+	storage   storage.Withdrawal
+	config    *config.Config
+	twoFactor *TwoFactorService
 }
 
-func newWithdrawService(storage storage.Withdrawal, config *config.Config) *WithdrawService {
-	return &WithdrawService{storage: storage, config: config}
+func newWithdrawService(
+	storage storage.Withdrawal,
+	config *config.Config,
+	twoFactor *TwoFactorService,
+) *WithdrawService {
+	return &WithdrawService{storage: storage, config: config, twoFactor: twoFactor}
 }
 
 func (ws *WithdrawService) GetBalance(ctx context.Context, userID int) (*domain.BalanceOut, error) {
+	ctx, span := tracing.Start(ctx, "WithdrawService.GetBalance")
+	defer span.End()
 	balance, err := ws.storage.GetBalance(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get balance for user %d: %w", userID, err)
@@ -29,19 +38,100 @@ func (ws *WithdrawService) GetBalance(ctx context.Context, userID int) (*domain.
 }
 
 func (ws *WithdrawService) WithdrawBonuses(ctx context.Context, userID int, withdraw *domain.WithdrawalIn) error {
+	ctx, span := tracing.Start(ctx, "WithdrawService.WithdrawBonuses")
+	defer span.End()
 	if err := goluhn.Validate(withdraw.OrderNumber); err != nil {
 		return errs.ErrInvalidOrderNumber
 	}
-	if err := ws.storage.WithdrawBonuses(ctx, userID, withdraw); err != nil {
+	required, err := ws.secondFactorRequired(ctx, userID, withdraw)
+	if err != nil {
+		return err
+	}
+	// The factor is used up in the withdrawal transaction, so a failed withdrawal leaves the code valid.
+	var factor *domain.SecondFactor
+	if required {
+		if withdraw.OTPCode == "" {
+			return errs.ErrSecondFactorRequired
+		}
+		if factor, err = ws.twoFactor.MatchSecondFactor(ctx, userID, withdraw.OTPCode); err != nil {
+			return fmt.Errorf("failed to verify second factor for user %d: %w", userID, err)
+		}
+	}
+	if err = ws.storage.WithdrawBonuses(ctx, userID, withdraw, factor); err != nil {
 		return fmt.Errorf("failed to withdraw bonuses for user %d: %w", userID, err)
 	}
 	return nil
 }
 
-func (ws *WithdrawService) GetAllWithdrawals(ctx context.Context, userID int) (domain.WithdrawOutList, error) {
-	withdrawals, err := ws.storage.GetAllWithdrawals(ctx, userID)
+func (ws *WithdrawService) GetAllWithdrawals(
+	ctx context.Context,
+	query *domain.WithdrawalQuery,
+) (*domain.WithdrawalPage, error) {
+	ctx, span := tracing.Start(ctx, "WithdrawService.GetAllWithdrawals")
+	defer span.End()
+	page, err := listWithdrawals(ctx, ws.storage, query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get all withdrawals for user %d: %w", userID, err)
+		return nil, fmt.Errorf("failed to get withdrawals for user %d: %w", query.UserID, err)
+	}
+	return page, nil
+}
+
+func (ws *WithdrawService) GetStatement(ctx context.Context, query *domain.StatementQuery) (*domain.Statement, error) {
+	ctx, span := tracing.Start(ctx, "WithdrawService.GetStatement")
+	defer span.End()
+	statement, err := ws.storage.GetStatement(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get statement for user %d: %w", query.UserID, err)
+	}
+	return statement, nil
+}
+
+func (ws *WithdrawService) ExportStatement(
+	ctx context.Context,
+	query *domain.StatementQuery,
+	fn func(entry *domain.LedgerEntry) error,
+) error {
+	ctx, span := tracing.Start(ctx, "WithdrawService.ExportStatement")
+	defer span.End()
+	if err := ws.storage.StreamStatement(ctx, query, fn); err != nil {
+		return fmt.Errorf("failed to export statement for user %d: %w", query.UserID, err)
+	}
+	return nil
+}
+
+// ExportWithdrawals streams every withdrawal matching the filters of query, ignoring its paging.
+func (ws *WithdrawService) ExportWithdrawals(
+	ctx context.Context,
+	query *domain.WithdrawalQuery,
+	fn func(withdrawal *domain.WithdrawalRecord) error,
+) error {
+	ctx, span := tracing.Start(ctx, "WithdrawService.ExportWithdrawals")
+	defer span.End()
+	query.Limit, query.Cursor, query.After = 0, "", nil
+	if err := ws.storage.StreamWithdrawals(ctx, query, fn); err != nil {
+		return fmt.Errorf("failed to export withdrawals for user %d: %w", query.UserID, err)
+	}
+	return nil
+}
+
+// secondFactorRequired reports whether the withdrawal needs a code: it is above the threshold, the user has
+// TOTP enabled and has not passed a second factor within the freshness window.
+func (ws *WithdrawService) secondFactorRequired(
+	ctx context.Context,
+	userID int,
+	withdraw *domain.WithdrawalIn,
+) (bool, error) {
+	if float64(withdraw.Sum) <= ws.config.MFAWithdrawThreshold {
+		return false, nil
+	}
+	enabled, err := ws.twoFactor.IsEnabled(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check second factor for user %d: %w", userID, err)
+	}
+	if !enabled {
+		return false, nil
 	}
-	return withdrawals, nil
+	freshness := time.Duration(ws.config.MFAFreshnessSeconds) * time.Second
+	principal, ok := domain.PrincipalFromContext(ctx)
+	return !ok || principal.MFAAt.IsZero() || time.Since(principal.MFAAt) > freshness, nil
 }
@@ -3,11 +3,18 @@ package errs
 import "errors"
 
 var (
-	ErrValidationError = errors.New("validation error")
-	ErrNotFound        = errors.New("element not found")
+	ErrValidationError      = errors.New("validation error")
+	ErrNotFound             = errors.New("element not found")
+	ErrMalformedBody        = errors.New("malformed request body")
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+	ErrPayloadTooLarge      = errors.New("request body is too large")
+	ErrUnauthorized         = errors.New("unauthorized")
+	ErrForbidden            = errors.New("forbidden")
+	ErrTooManyRequests      = errors.New("too many requests")
 
 	ErrLoginAlreadyExist      = errors.New("login already exist")
 	ErrInvalidLoginOrPassword = errors.New("invalid login or password")
+	ErrInvalidResetToken      = errors.New("password reset token is invalid or expired")
 
 	ErrInvalidOrderNumber = errors.New("invalid order number")
 	ErrOrderAlreadyAdded  = errors.New("order has already been added")
@@ -15,4 +22,12 @@ var (
 
 	ErrWithdrawAlreadyExist = errors.New("withdraw for this order already exist")
 	ErrNotEnoughFunds       = errors.New("not enough bonuses to withdraw")
+
+	ErrSecondFactorRequired    = errors.New("fresh second factor is required")
+	ErrInvalidSecondFactor     = errors.New("invalid second factor code")
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication already enabled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+
+	ErrIdempotencyKeyInUse  = errors.New("a request with this idempotency key is still in progress")
+	ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different request")
 )
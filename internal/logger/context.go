@@ -0,0 +1,41 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"go.uber.org/zap"
+)
+
+const correlationIDBytes = 16
+
+type ctxKey struct{}
+
+// WithContext stores l in ctx so that everything downstream logs with its fields.
+func WithContext(ctx context.Context, l *zap.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// With returns ctx carrying the context logger extended with fields. The fields are encoded
+// lazily, on the first write, so values known only later in the request still make it in.
+func With(ctx context.Context, fields ...zap.Field) context.Context {
+	return WithContext(ctx, FromContext(ctx).WithLazy(fields...))
+}
+
+// FromContext returns the logger stored in ctx, or Log when there is none.
+func FromContext(ctx context.Context) *zap.Logger {
+	if l, ok := ctx.Value(ctxKey{}).(*zap.Logger); ok {
+		return l
+	}
+	return Log
+}
+
+// NewCorrelationID returns a random hex id for tying log lines of one request or job together.
+func NewCorrelationID() string {
+	b := make([]byte, correlationIDBytes)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -8,13 +8,17 @@ import (
 
 var Log *zap.Logger = zap.NewNop()
 
+// Level controls the verbosity of Log and can be changed while the app is running.
+var Level = zap.NewAtomicLevel()
+
 func Initialize(level string) error {
 	lvl, err := zap.ParseAtomicLevel(level)
 	if err != nil {
 		return fmt.Errorf("failed to parse log level: %w", err)
 	}
+	Level.SetLevel(lvl.Level())
 	cfg := zap.NewProductionConfig()
-	cfg.Level = lvl
+	cfg.Level = Level
 	zl, err := cfg.Build()
 	if err != nil {
 		return fmt.Errorf("failed to build log config: %w", err)
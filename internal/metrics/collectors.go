@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"context"
+	"gophermart/internal/logger"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+const refreshTimeout = 2 * time.Second
+
+// poolCollector reads pgx pool statistics at scrape time.
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	acquired        *prometheus.Desc
+	idle            *prometheus.Desc
+	total           *prometheus.Desc
+	max             *prometheus.Desc
+	acquires        *prometheus.Desc
+	acquireDuration *prometheus.Desc
+	canceled        *prometheus.Desc
+	empty           *prometheus.Desc
+}
+
+func NewPoolCollector(pool *pgxpool.Pool) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "pgx_pool", name), help, nil, nil)
+	}
+	return &poolCollector{
+		pool:            pool,
+		acquired:        desc("acquired_conns", "Connections currently in use."),
+		idle:            desc("idle_conns", "Idle connections."),
+		total:           desc("total_conns", "All open connections."),
+		max:             desc("max_conns", "Maximum size of the pool."),
+		acquires:        desc("acquires_total", "Successful connection acquisitions."),
+		acquireDuration: desc("acquire_duration_seconds_total", "Time spent acquiring connections."),
+		canceled:        desc("canceled_acquires_total", "Acquisitions canceled by their context."),
+		empty:           desc("empty_acquires_total", "Acquisitions that had to wait for a connection."),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(c.acquired, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.max, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquires, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.canceled, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.empty, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+}
+
+// LiabilityCollector reports the bonuses owed to users. The total is a sum over all users, so Run refreshes
+// it on an interval instead of querying on every scrape. Every instance still reports the same total.
+type LiabilityCollector struct {
+	liability func(ctx context.Context) (float64, error)
+	desc      *prometheus.Desc
+	value     atomic.Pointer[float64]
+}
+
+func NewLiabilityCollector(liability func(ctx context.Context) (float64, error)) *LiabilityCollector {
+	return &LiabilityCollector{
+		liability: liability,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bonus", "outstanding_liability"),
+			"Bonuses accrued and adjusted minus withdrawn, over all users.",
+			nil, nil,
+		),
+	}
+}
+
+// Run refreshes the total every interval until ctx is done.
+func (c *LiabilityCollector) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		c.refresh(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *LiabilityCollector) refresh(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, refreshTimeout)
+	defer cancel()
+	value, err := c.liability(ctx)
+	if err != nil {
+		// The last known total stays in place.
+		logger.Log.Error("failed to refresh outstanding liability", zap.Error(err))
+		return
+	}
+	c.value.Store(&value)
+}
+
+func (c *LiabilityCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect skips the sample until the first refresh succeeded, which keeps the rest of the scrape intact.
+func (c *LiabilityCollector) Collect(ch chan<- prometheus.Metric) {
+	if value := c.value.Load(); value != nil {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, *value)
+	}
+}
@@ -0,0 +1,153 @@
+// Package pdf writes simple text-only PDF documents in the built-in Courier font.
+// Pages are written out as soon as they fill up, so memory use does not grow with the document.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	pageWidth    = 595 // A4 in points
+	pageHeight   = 842
+	margin       = 40
+	fontSize     = 9
+	lineHeight   = 12
+	linesPerPage = (pageHeight - 2*margin) / lineHeight
+	del          = 0x7F
+	nbsp         = 0xA0
+	maxLatin1    = 0xFF
+
+	catalogObject = 1
+	pagesObject   = 2
+	fontObject    = 3
+	firstFree     = 4
+)
+
+type Writer struct {
+	w       *countingWriter
+	offsets map[int]int64
+	pages   []int
+	next    int
+	header  []string
+	lines   []string
+	err     error
+}
+
+// NewWriter starts a document. header lines are repeated at the top of every page.
+func NewWriter(w io.Writer, header ...string) *Writer {
+	p := &Writer{
+		w:       &countingWriter{w: w},
+		offsets: make(map[int]int64),
+		next:    firstFree,
+		header:  header,
+	}
+	p.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+	p.object(fontObject, "<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	return p
+}
+
+// WriteLine adds a line of text, starting a new page when the current one is full.
+func (p *Writer) WriteLine(line string) error {
+	if len(p.lines) == 0 {
+		p.lines = append(p.lines, p.header...)
+	}
+	p.lines = append(p.lines, line)
+	if len(p.lines) >= linesPerPage {
+		p.flushPage()
+	}
+	return p.err
+}
+
+// Close writes the remaining page, the page tree and the cross-reference table.
+func (p *Writer) Close() error {
+	if len(p.lines) > 0 || len(p.pages) == 0 {
+		if len(p.lines) == 0 {
+			p.lines = append(p.lines, p.header...)
+		}
+		p.flushPage()
+	}
+	kids := make([]string, 0, len(p.pages))
+	for _, page := range p.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+	}
+	p.object(pagesObject, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages)))
+	p.object(catalogObject, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObject))
+	xref := p.w.n
+	p.printf("xref\n0 %d\n0000000000 65535 f \n", p.next)
+	for id := 1; id < p.next; id++ {
+		p.printf("%010d 00000 n \n", p.offsets[id])
+	}
+	p.printf("trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", p.next, catalogObject, xref)
+	return p.err
+}
+
+func (p *Writer) flushPage() {
+	var content bytes.Buffer
+	fmt.Fprintf(&content, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", fontSize, lineHeight, margin, pageHeight-margin)
+	for _, line := range p.lines {
+		fmt.Fprintf(&content, "(%s) Tj T*\n", escape(line))
+	}
+	content.WriteString("ET")
+	contentID, pageID := p.allocate(), p.allocate()
+	p.object(contentID, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.Bytes()))
+	p.object(pageID, fmt.Sprintf(
+		"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+		pagesObject, pageWidth, pageHeight, fontObject, contentID,
+	))
+	p.pages = append(p.pages, pageID)
+	p.lines = p.lines[:0]
+}
+
+func (p *Writer) allocate() int {
+	id := p.next
+	p.next++
+	return id
+}
+
+func (p *Writer) object(id int, body string) {
+	p.offsets[id] = p.w.n
+	p.printf("%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+func (p *Writer) printf(format string, args ...any) {
+	if p.err != nil {
+		return
+	}
+	if _, err := fmt.Fprintf(p.w, format, args...); err != nil {
+		p.err = fmt.Errorf("failed to write pdf: %w", err)
+	}
+}
+
+// escape encodes s as a PDF string in WinAnsi, replacing characters the font can't show.
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < ' ' || (r >= del && r < nbsp) || r > maxLatin1:
+			b.WriteByte('?')
+		default:
+			b.WriteByte(byte(r))
+		}
+	}
+	return b.String()
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	if err != nil {
+		return n, fmt.Errorf("failed to write: %w", err)
+	}
+	return n, nil
+}